@@ -6,8 +6,23 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"time"
 )
 
+// ErrUnsupported is returned when the dialect does not support the feature.
+var ErrUnsupported = errors.New("unsupported by dialect")
+
+// ErrFullTable is returned for UPDATE/DELETE without any condition.
+var ErrFullTable = errors.New("refused to affect whole table without AllowFullTable()")
+
+// ErrStaleObject is returned when Update by struct with version field
+// affected no row, because the row was modified or deleted by others.
+var ErrStaleObject = errors.New("stale object")
+
+// ErrScanMismatch is returned by strict scanning when columns and fields differ.
+var ErrScanMismatch = errors.New("columns and fields mismatch")
+
 type DB interface {
 	Begin(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 	Close() error
@@ -15,10 +30,21 @@ type DB interface {
 	Ping(ctx context.Context) error
 	SetMaxIdleConns(n int)
 	SetMaxOpenConns(n int)
+	Dialect() Dialect
+
+	// SetScanMode sets the default ScanMode of statements, ScanLoose at first.
+	SetScanMode(mode ScanMode)
+
+	// SetTimePolicy sets normalization of times in binds and scans.
+	SetTimePolicy(p TimePolicy)
+
+	// SetClock replaces time.Now giving autocreate/autoupdate timestamps.
+	SetClock(now func() time.Time)
 
 	QueryRunner
 
-	// for customize original provider
+	// for customize original provider. Statements of aqua are not run by
+	// it, so its callbacks and logging do not apply to them.
 	GetProvider() interface{}
 }
 
@@ -31,6 +57,10 @@ type Tx interface {
 
 type QueryRunner interface {
 	Table(name string) StmtTable
+
+	// Exec rebinds `?` outside quotes into placeholders of the dialect, like
+	// $1 of postgres, so operators like jsonb `?` should be written as
+	// functions such as jsonb_exists.
 	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
@@ -41,7 +71,16 @@ type StmtTable interface {
 	RightJoin(table, condition string) StmtTable
 	Select(columns ...string) StmtTable
 
+	// Model binds the struct type of v, whose soft delete marker scopes
+	// queries of the statement to rows not deleted. It defaults to the model
+	// registered for the table by RegisterModel.
+	Model(v interface{}) StmtTable
+
 	Create(ctx context.Context, values ...interface{}) error
+
+	// InsertFrom copies rows selected by source into the table on the server side.
+	// Columns selected by source default to columns.
+	InsertFrom(ctx context.Context, columns []string, source StmtAggregate) (int64, error)
 }
 
 type StmtCondition interface {
@@ -53,6 +92,17 @@ type StmtCondition interface {
 	WhereIn(column string, values ...interface{}) StmtCondition
 	WhereBetween(column string, a, b interface{}) StmtCondition
 	WhereLike(column, pattern string) StmtCondition
+
+	// WhereJSON compares the value at path, like "theme.color", in JSON
+	// column on every dialect. Number is compared numerically with JSON
+	// number, so 3 matches 3 and 3.0. Other value is compared with the text
+	// in JSON notation, so "3" matches 3 but not 3.0, and true matches true.
+	// nil matches JSON null and missing keys.
+	WhereJSON(column, path string, value interface{}) StmtCondition
+
+	// WithDeleted includes soft deleted rows, OnlyDeleted selects them only.
+	WithDeleted() StmtCondition
+	OnlyDeleted() StmtCondition
 }
 
 type StmtAggregate interface {
@@ -63,6 +113,26 @@ type StmtAggregate interface {
 	OrderBy(columns ...string) StmtAggregate
 	Having(condition string) StmtAggregate
 	LimitOffset(limit, offset int) StmtAggregate
+
+	// Clone returns a copy of the statement, which is not affected by
+	// further calls on the original.
+	Clone() StmtAggregate
+
+	// Each walks rows in batches of batchSize using keyset pagination on the
+	// primary keys of the element of dest, a pointer to slice used as buffer.
+	// fn receives the slice. An error from fn stops the walk and is returned.
+	Each(ctx context.Context, batchSize int, dest interface{}, fn func(batch interface{}) error) error
+
+	// Paginate fetches up to limit rows after the cursor into dest in the
+	// order of OrderBy, which should consist of plain columns with optional
+	// ASC/DESC. Primary keys are added as tie breakers. NULL of nullable
+	// field sorts as smaller than any value on every dialect. Returned
+	// cursor points to the next page, or is empty on the last page.
+	Paginate(ctx context.Context, after Cursor, limit int, dest interface{}) (Cursor, error)
+
+	// Page fetches rows of the 1-origin page into dest, a pointer to slice,
+	// along with the total count ignoring OrderBy and LimitOffset.
+	Page(ctx context.Context, page, perPage int, dest interface{}) (PageInfo, error)
 }
 
 type StmtRunner interface {
@@ -71,12 +141,63 @@ type StmtRunner interface {
 	FetchColumn(ctx context.Context, column string) (Rows, error)
 	Count(ctx context.Context) (int, error)
 
+	// Update by struct writes the row of its primary keys, which must be
+	// non-zero, skipping zero fields. Update by map writes rows matching
+	// conditions. Version field is checked and incremented.
+	Update(ctx context.Context, v interface{}) error
+
+	// Delete by struct removes the row of its primary keys, Delete(ctx, nil)
+	// rows matching conditions. Soft delete marker of the model is set to
+	// current time instead.
+	Delete(ctx context.Context, v interface{}) error
+
+	// HardDelete is Delete removing rows even with soft delete marker.
+	HardDelete(ctx context.Context, v interface{}) error
+
+	Increment(ctx context.Context, column string, delta int) error
+	Decrement(ctx context.Context, column string, delta int) error
+
+	// Returning makes Create/Update/Delete fetch the columns of affected rows.
+	// No columns means all columns.
+	Returning(columns ...string) StmtReturning
+
+	// AllowFullTable permits Update/Delete without any condition.
+	AllowFullTable() StmtRunner
+
+	// Only and Omit restrict fields of struct, or keys of map, written by
+	// Create/Update to the columns, or to the others. Zero value of the
+	// column named by Only is written too.
+	Only(columns ...string) StmtWrite
+	Omit(columns ...string) StmtWrite
+
+	// Since gives the image of the struct before changes to Update, which
+	// then writes changed fields only, zero ones included, like Snapshot.
+	Since(before interface{}) StmtRunner
+
+	// WithScanMode overrides ScanMode of the DB for this statement.
+	WithScanMode(mode ScanMode) StmtRunner
+}
+
+type StmtWrite interface {
+	Only(columns ...string) StmtWrite
+	Omit(columns ...string) StmtWrite
+
+	Create(ctx context.Context, values ...interface{}) error
+	Update(ctx context.Context, v interface{}) error
+}
+
+type StmtReturning interface {
+	// Into sets a pointer to slice receiving every returned row. Without
+	// Into, returned row is scanned into the struct passed to the runner.
+	Into(dest interface{}) StmtReturning
+
+	Create(ctx context.Context, values ...interface{}) error
 	Update(ctx context.Context, v interface{}) error
 	Delete(ctx context.Context, v interface{}) error
 }
 
 type Row interface {
-	ScanRow(dest interface{}) error
+	ScanRow(dest interface{}) error // into struct or *map[string]interface{}
 	Scan(dest ...interface{}) error
 }
 
@@ -87,5 +208,6 @@ type Rows interface {
 	Next() bool
 
 	Scan(dest ...interface{}) error // sql.Rows 's Scan()
-	ScanAll(dest interface{}) error
+	ScanRow(dest interface{}) error // scan current row into struct or *map[string]interface{}
+	ScanAll(dest interface{}) error // into slice of struct or map[string]interface{}
 }
@@ -0,0 +1,119 @@
+package aqua
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Cursor is an opaque position for keyset pagination. Empty cursor means
+// the first page when passed, and no more pages when returned.
+type Cursor string
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// EncodeCursor encodes sort key values of a row. NULL is decoded as nil.
+func EncodeCursor(values []interface{}) (Cursor, error) {
+	encoded := make([]cursorValue, 0, len(values))
+	for _, v := range values {
+		cv := cursorValue{}
+
+		// sort key of nullable type
+		if valuer, ok := v.(driver.Valuer); ok {
+			dv, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+			v = dv
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			v = rv.Elem().Interface()
+		}
+
+		rv := reflect.ValueOf(v)
+		if v == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+			cv.Type = "n"
+			encoded = append(encoded, cv)
+			continue
+		}
+
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			cv.Type, cv.Value = "i", strconv.FormatInt(rv.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			cv.Type, cv.Value = "u", strconv.FormatUint(rv.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			cv.Type, cv.Value = "f", strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+		case reflect.String:
+			cv.Type, cv.Value = "s", rv.String()
+		case reflect.Bool:
+			cv.Type, cv.Value = "b", strconv.FormatBool(rv.Bool())
+		default:
+			t, ok := v.(time.Time)
+			if !ok {
+				return "", fmt.Errorf(`cannot use %T as cursor value`, v)
+			}
+			cv.Type, cv.Value = "t", t.Format(time.RFC3339Nano)
+		}
+
+		encoded = append(encoded, cv)
+	}
+
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return Cursor(base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// Values decodes sort key values of the cursor.
+func (c Cursor) Values() ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return nil, fmt.Errorf(`invalid cursor: %w`, err)
+	}
+
+	encoded := []cursorValue{}
+	err = json.Unmarshal(b, &encoded)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid cursor: %w`, err)
+	}
+
+	values := make([]interface{}, 0, len(encoded))
+	for _, cv := range encoded {
+		var v interface{}
+		switch cv.Type {
+		case "n":
+			v = nil
+		case "i":
+			v, err = strconv.ParseInt(cv.Value, 10, 64)
+		case "u":
+			v, err = strconv.ParseUint(cv.Value, 10, 64)
+		case "f":
+			v, err = strconv.ParseFloat(cv.Value, 64)
+		case "s":
+			v = cv.Value
+		case "b":
+			v, err = strconv.ParseBool(cv.Value)
+		case "t":
+			v, err = time.Parse(time.RFC3339Nano, cv.Value)
+		default:
+			err = fmt.Errorf(`unknown type "%s"`, cv.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`invalid cursor: %w`, err)
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
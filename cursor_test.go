@@ -0,0 +1,54 @@
+package aqua
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	now := time.Date(2015, 4, 1, 12, 34, 56, 789, time.UTC)
+	values := []interface{}{int64(-1), uint64(2), 3.5, "foo,bar", true, now}
+
+	c, err := EncodeCursor(values)
+	if err != nil {
+		t.Fatalf(`failed to encode cursor: %s`, err)
+	}
+
+	actual, err := c.Values()
+	if err != nil {
+		t.Fatalf(`failed to decode cursor: %s`, err)
+	}
+	if !reflect.DeepEqual(actual[:5], values[:5]) {
+		t.Errorf(`expected values are %v, but actual %v`, values, actual)
+	}
+	if !actual[5].(time.Time).Equal(now) {
+		t.Errorf(`expected time is %v, but actual %v`, now, actual[5])
+	}
+
+	c, err = EncodeCursor([]interface{}{NewNull("foo"), &now})
+	if err != nil {
+		t.Fatalf(`failed to encode cursor: %s`, err)
+	}
+	actual, err = c.Values()
+	if err != nil || actual[0] != "foo" || !actual[1].(time.Time).Equal(now) {
+		t.Errorf(`unexpected values %v (%v)`, actual, err)
+	}
+
+	c, err = EncodeCursor([]interface{}{Null[int]{}, (*string)(nil), nil, 1})
+	if err != nil {
+		t.Fatalf(`failed to encode NULL: %s`, err)
+	}
+	actual, err = c.Values()
+	if err != nil || !reflect.DeepEqual(actual, []interface{}{nil, nil, nil, int64(1)}) {
+		t.Errorf(`unexpected values %v (%v)`, actual, err)
+	}
+	_, err = EncodeCursor([]interface{}{[]int{1}})
+	if err == nil {
+		t.Errorf(`expected error for slice value`)
+	}
+	_, err = Cursor("!!").Values()
+	if err == nil {
+		t.Errorf(`expected error for broken cursor`)
+	}
+}
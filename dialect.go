@@ -0,0 +1,195 @@
+package aqua
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect absorbs SQL syntax differences between database drivers.
+type Dialect interface {
+	Name() string
+
+	// Quote quotes an identifier such as `order` or `test.person_id`.
+	// Strings which are not plain identifiers (expressions, `*`, already
+	// quoted names) are returned as is.
+	Quote(ident string) string
+
+	// Placeholder returns the n-th (1-origin) bind parameter marker.
+	Placeholder(n int) string
+
+	// LimitOffset returns LIMIT/OFFSET clause. limit <= 0 means no limit.
+	LimitOffset(limit, offset int) string
+
+	// Upsert returns the clause appended to INSERT statement to update
+	// columns when a row conflicting on keys already exists.
+	Upsert(keys, columns []string) string
+
+	Bool(b bool) string
+	SupportsReturning() bool
+
+	// JSONExtract returns an expression of the text at path, keys joined by
+	// dots like "theme.color", in JSON column. Strings are unquoted, numbers
+	// and booleans are in JSON notation like "3" and "true", JSON null and
+	// missing keys are NULL.
+	JSONExtract(column, path string) string
+	// JSONNumber returns an expression of the number at path to compare
+	// numerically, which is NULL when it is not a number.
+	JSONNumber(column, path string) string
+}
+
+// ServerVersioned is implemented by dialects whose features depend on the
+// version of the database. Providers should query the version on open and
+// use the dialect given by WithVersion.
+type ServerVersioned interface {
+	VersionQuery() string
+	WithVersion(version string) Dialect
+}
+
+// versionAtLeast compares version like "3.35.0" with minimum numbers.
+func versionAtLeast(version string, min ...int) bool {
+	parts := strings.Split(version, ".")
+	for i, m := range min {
+		n := 0
+		if i < len(parts) {
+			n, _ = strconv.Atoi(strings.TrimFunc(parts[i], func(r rune) bool {
+				return r < '0' || '9' < r
+			}))
+		}
+		if n != m {
+			return n > m
+		}
+	}
+	return true
+}
+
+var dialects map[string]Dialect = make(map[string]Dialect)
+
+func init() {
+	RegisterDialect("sqlite3", &sqlite3Dialect{})
+	RegisterDialect("mysql", &mysqlDialect{})
+	RegisterDialect("postgres", &postgresDialect{})
+}
+
+// RegisterDialect makes a dialect available for the driver.
+func RegisterDialect(driver string, d Dialect) {
+	dialects[driver] = d
+}
+
+// GetDialect returns the dialect registered for the driver.
+func GetDialect(driver string) (Dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("no such dialect for driver: %s", driver)
+	}
+
+	return d, nil
+}
+
+// Rebind rewrites `?` placeholders in query into the style of the dialect.
+// Question marks in quoted strings or identifiers are left untouched.
+func Rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+
+	buf := strings.Builder{}
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			buf.WriteString(d.Placeholder(n))
+			continue
+		}
+		buf.WriteRune(c)
+	}
+
+	return buf.String()
+}
+
+var (
+	identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.([A-Za-z_][A-Za-z0-9_]*|\*))*$`)
+	aliasPattern = regexp.MustCompile(`^(\S+)\s+(?i:AS)\s+([A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?)$`)
+)
+
+// quoteIdent quotes "table.column" and "table.column AS alias", where alias
+// may be qualified like "person.id" and is quoted as a whole
+func quoteIdent(ident, quote string) string {
+	if m := aliasPattern.FindStringSubmatch(ident); m != nil && identPattern.MatchString(m[1]) {
+		return quoteIdent(m[1], quote) + " AS " + quote + m[2] + quote
+	}
+	if !identPattern.MatchString(ident) {
+		return ident
+	}
+
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		if p != "*" {
+			parts[i] = quote + p + quote
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func quoteIdents(d Dialect, idents []string) []string {
+	result := make([]string, 0, len(idents))
+	for _, v := range idents {
+		result = append(result, d.Quote(v))
+	}
+	return result
+}
+
+func limitOffset(limit, offset int, unlimited string) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		if unlimited == "" {
+			return fmt.Sprintf("OFFSET %d", offset)
+		}
+		return fmt.Sprintf("LIMIT %s OFFSET %d", unlimited, offset)
+	}
+	return ""
+}
+
+// literal quotes s as SQL string literal
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// jsonPath converts "theme.color" into `$."theme"."color"`
+func jsonPath(path string) string {
+	keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
+	buf := strings.Builder{}
+	buf.WriteString("$")
+	for _, k := range keys {
+		buf.WriteString(`."` + strings.ReplaceAll(k, `"`, `\"`) + `"`)
+	}
+	return buf.String()
+}
+
+func onConflict(d Dialect, keys, columns []string) string {
+	target := strings.Join(quoteIdents(d, keys), ", ")
+	if len(columns) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", target)
+	}
+
+	sets := make([]string, 0, len(columns))
+	for _, c := range columns {
+		q := d.Quote(c)
+		sets = append(sets, fmt.Sprintf("%s = excluded.%s", q, q))
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", target, strings.Join(sets, ", "))
+}
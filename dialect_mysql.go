@@ -0,0 +1,63 @@
+package aqua
+
+import (
+	"fmt"
+	"strings"
+)
+
+// for github.com/go-mysql-driver/mysql
+type mysqlDialect struct{}
+
+func (d *mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (d *mysqlDialect) Quote(ident string) string {
+	return quoteIdent(ident, "`")
+}
+
+func (d *mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *mysqlDialect) LimitOffset(limit, offset int) string {
+	// MySQL has no way to write OFFSET without LIMIT
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+func (d *mysqlDialect) Upsert(keys, columns []string) string {
+	// MySQL decides conflicts by every unique key, so keys are not used
+	// except for the "do nothing" case
+	if len(columns) == 0 {
+		columns = keys
+	}
+
+	sets := make([]string, 0, len(columns))
+	for _, c := range columns {
+		q := d.Quote(c)
+		sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", q, q))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+func (d *mysqlDialect) Bool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (d *mysqlDialect) SupportsReturning() bool {
+	return false
+}
+
+// JSON null is unquoted into 'null', so it is turned into NULL
+func (d *mysqlDialect) JSONExtract(column, path string) string {
+	extract := fmt.Sprintf("JSON_EXTRACT(%s, %s)", d.Quote(column), literal(jsonPath(path)))
+	return fmt.Sprintf("CASE JSON_TYPE(%s) WHEN 'NULL' THEN NULL ELSE JSON_UNQUOTE(%s) END", extract, extract)
+}
+
+func (d *mysqlDialect) JSONNumber(column, path string) string {
+	extract := fmt.Sprintf("JSON_EXTRACT(%s, %s)", d.Quote(column), literal(jsonPath(path)))
+	return fmt.Sprintf("CASE WHEN JSON_TYPE(%s) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL') THEN %s END", extract, extract)
+}
@@ -0,0 +1,64 @@
+package aqua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// for github.com/lib/pq
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) Quote(ident string) string {
+	return quoteIdent(ident, `"`)
+}
+
+func (d *postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (d *postgresDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset, "")
+}
+
+func (d *postgresDialect) Upsert(keys, columns []string) string {
+	return onConflict(d, keys, columns)
+}
+
+func (d *postgresDialect) Bool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (d *postgresDialect) SupportsReturning() bool {
+	return true
+}
+
+func (d *postgresDialect) JSONExtract(column, path string) string {
+	return d.jsonKeys(column, path, "->>")
+}
+
+func (d *postgresDialect) JSONNumber(column, path string) string {
+	// json_typeof accepts jsonb cast to json as well
+	return fmt.Sprintf("CASE json_typeof((%s)::json) WHEN 'number' THEN (%s)::numeric END", d.jsonKeys(column, path, "->"), d.jsonKeys(column, path, "->>"))
+}
+
+// jsonKeys chains keys of path by "->", and the last one by op
+func (d *postgresDialect) jsonKeys(column, path, op string) string {
+	keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
+	expr := d.Quote(column)
+	for i, k := range keys {
+		if i == len(keys)-1 {
+			expr += op + literal(k)
+		} else {
+			expr += "->" + literal(k)
+		}
+	}
+	return expr
+}
@@ -0,0 +1,62 @@
+package aqua
+
+import (
+	"fmt"
+)
+
+// for github.com/mattn/go-sqlite3
+type sqlite3Dialect struct {
+	version string // of the library, empty when unknown
+}
+
+func (d *sqlite3Dialect) Name() string {
+	return "sqlite3"
+}
+
+func (d *sqlite3Dialect) Quote(ident string) string {
+	return quoteIdent(ident, `"`)
+}
+
+func (d *sqlite3Dialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *sqlite3Dialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset, "-1")
+}
+
+func (d *sqlite3Dialect) Upsert(keys, columns []string) string {
+	return onConflict(d, keys, columns)
+}
+
+func (d *sqlite3Dialect) Bool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// RETURNING is available since SQLite 3.35.0
+func (d *sqlite3Dialect) SupportsReturning() bool {
+	return d.version == "" || versionAtLeast(d.version, 3, 35)
+}
+
+func (d *sqlite3Dialect) VersionQuery() string {
+	return "SELECT sqlite_version()"
+}
+
+func (d *sqlite3Dialect) WithVersion(version string) Dialect {
+	return &sqlite3Dialect{version: version}
+}
+
+// json_extract gives SQL values, so booleans are spelled and the rest
+// are cast into text
+func (d *sqlite3Dialect) JSONExtract(column, path string) string {
+	args := fmt.Sprintf("%s, %s", d.Quote(column), literal(jsonPath(path)))
+	return fmt.Sprintf("CASE json_type(%s) WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract(%s) AS TEXT) END", args, args)
+}
+
+func (d *sqlite3Dialect) JSONNumber(column, path string) string {
+	args := fmt.Sprintf("%s, %s", d.Quote(column), literal(jsonPath(path)))
+	return fmt.Sprintf("CASE json_type(%s) WHEN 'integer' THEN json_extract(%s) WHEN 'real' THEN json_extract(%s) END", args, args, args)
+}
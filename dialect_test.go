@@ -0,0 +1,165 @@
+package aqua
+
+import (
+	"testing"
+)
+
+func mustDialect(t *testing.T, driver string) Dialect {
+	d, err := GetDialect(driver)
+	if err != nil {
+		t.Fatalf(`failed to get dialect: %s`, err)
+	}
+	return d
+}
+
+func TestDialectQuote(t *testing.T) {
+	cases := []struct {
+		driver   string
+		ident    string
+		expected string
+	}{
+		{"sqlite3", "order", `"order"`},
+		{"sqlite3", "test.person_id", `"test"."person_id"`},
+		{"sqlite3", "person.*", `"person".*`},
+		{"sqlite3", "*", `*`},
+		{"sqlite3", "COUNT(*)", `COUNT(*)`},
+		{"mysql", "user", "`user`"},
+		{"mysql", "test.id", "`test`.`id`"},
+		{"mysql", "person.id AS person.id", "`person`.`id` AS `person.id`"},
+		{"sqlite3", "name as person_name", `"name" AS "person_name"`},
+		{"sqlite3", "COUNT(*) AS cnt", `COUNT(*) AS cnt`},
+		{"postgres", "user", `"user"`},
+		{"postgres", `"user"`, `"user"`},
+	}
+
+	for _, c := range cases {
+		actual := mustDialect(t, c.driver).Quote(c.ident)
+		if actual != c.expected {
+			t.Errorf(`%s: expected %s, but actual %s`, c.driver, c.expected, actual)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := `SELECT * FROM test WHERE id = ? AND data = '?' AND "a?" IN (?, ?)`
+
+	actual := Rebind(mustDialect(t, "postgres"), query)
+	expected := `SELECT * FROM test WHERE id = $1 AND data = '?' AND "a?" IN ($2, $3)`
+	if actual != expected {
+		t.Errorf(`expected %s, but actual %s`, expected, actual)
+	}
+
+	actual = Rebind(mustDialect(t, "mysql"), query)
+	if actual != query {
+		t.Errorf(`expected %s, but actual %s`, query, actual)
+	}
+}
+
+func TestDialectLimitOffset(t *testing.T) {
+	cases := []struct {
+		driver        string
+		limit, offset int
+		expected      string
+	}{
+		{"sqlite3", 10, 0, "LIMIT 10"},
+		{"sqlite3", 10, 20, "LIMIT 10 OFFSET 20"},
+		{"sqlite3", 0, 20, "LIMIT -1 OFFSET 20"},
+		{"sqlite3", 0, 0, ""},
+		{"mysql", 0, 20, "LIMIT 18446744073709551615 OFFSET 20"},
+		{"postgres", 0, 20, "OFFSET 20"},
+	}
+
+	for _, c := range cases {
+		actual := mustDialect(t, c.driver).LimitOffset(c.limit, c.offset)
+		if actual != c.expected {
+			t.Errorf(`%s: expected %s, but actual %s`, c.driver, c.expected, actual)
+		}
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	cases := []struct {
+		driver   string
+		expected string
+	}{
+		{"sqlite3", `ON CONFLICT ("id") DO UPDATE SET "data" = excluded."data"`},
+		{"postgres", `ON CONFLICT ("id") DO UPDATE SET "data" = excluded."data"`},
+		{"mysql", "ON DUPLICATE KEY UPDATE `data` = VALUES(`data`)"},
+	}
+
+	for _, c := range cases {
+		actual := mustDialect(t, c.driver).Upsert([]string{"id"}, []string{"data"})
+		if actual != c.expected {
+			t.Errorf(`%s: expected %s, but actual %s`, c.driver, c.expected, actual)
+		}
+	}
+
+	if _, err := GetDialect("oracle"); err == nil {
+		t.Errorf(`expected error for unknown driver`)
+	}
+}
+
+func TestDialectJSONExtract(t *testing.T) {
+	cases := []struct {
+		driver   string
+		path     string
+		expected string
+	}{
+		{"sqlite3", "theme", `CASE json_type("settings", '$."theme"') WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract("settings", '$."theme"') AS TEXT) END`},
+		{"sqlite3", "$.theme.color", `CASE json_type("settings", '$."theme"."color"') WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract("settings", '$."theme"."color"') AS TEXT) END`},
+		{"mysql", "theme.color", "CASE JSON_TYPE(JSON_EXTRACT(`settings`, '$.\"theme\".\"color\"')) WHEN 'NULL' THEN NULL ELSE JSON_UNQUOTE(JSON_EXTRACT(`settings`, '$.\"theme\".\"color\"')) END"},
+		{"postgres", "theme", `"settings"->>'theme'`},
+		{"postgres", "theme.it's", `"settings"->'theme'->>'it''s'`},
+	}
+
+	for _, c := range cases {
+		actual := mustDialect(t, c.driver).JSONExtract("settings", c.path)
+		if actual != c.expected {
+			t.Errorf(`%s: expected %s, but actual %s`, c.driver, c.expected, actual)
+		}
+	}
+}
+
+func TestDialectJSONNumber(t *testing.T) {
+	cases := []struct {
+		driver   string
+		path     string
+		expected string
+	}{
+		{"sqlite3", "level", `CASE json_type("extra", '$."level"') WHEN 'integer' THEN json_extract("extra", '$."level"') WHEN 'real' THEN json_extract("extra", '$."level"') END`},
+		{"mysql", "level", "CASE WHEN JSON_TYPE(JSON_EXTRACT(`extra`, '$.\"level\"')) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL') THEN JSON_EXTRACT(`extra`, '$.\"level\"') END"},
+		{"postgres", "stat.level", `CASE json_typeof(("extra"->'stat'->'level')::json) WHEN 'number' THEN ("extra"->'stat'->>'level')::numeric END`},
+	}
+
+	for _, c := range cases {
+		actual := mustDialect(t, c.driver).JSONNumber("extra", c.path)
+		if actual != c.expected {
+			t.Errorf(`%s: expected %s, but actual %s`, c.driver, c.expected, actual)
+		}
+	}
+}
+
+func TestDialectServerVersion(t *testing.T) {
+	d := mustDialect(t, "sqlite3")
+	v, ok := d.(ServerVersioned)
+	if !ok {
+		t.Fatalf(`sqlite3 dialect should depend on version`)
+	}
+
+	cases := []struct {
+		version  string
+		expected bool
+	}{
+		{"3.34.1", false},
+		{"3.35.0", true},
+		{"3.45.1", true},
+		{"4.0", true},
+		{"2.8.17", false},
+	}
+	for _, c := range cases {
+		actual := v.WithVersion(c.version).SupportsReturning()
+		if actual != c.expected {
+			t.Errorf(`%s: expected %v, but actual %v`, c.version, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package aqua
+
+// Expression is a raw SQL fragment written as a value of Create or Update,
+// such as `SET views = views + 1` or `SET updated_at = CURRENT_TIMESTAMP`.
+type Expression struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Expr makes an Expression. `?` in sql are bound to args.
+func Expr(sql string, args ...interface{}) Expression {
+	return Expression{
+		SQL:  sql,
+		Args: args,
+	}
+}
@@ -0,0 +1,197 @@
+package aqua
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// Query is a statically typed builder whose rows are scanned into T.
+type Query[T any] struct {
+	runner QueryRunner
+	table  string
+	stmt   StmtAggregate
+	limit  int
+	offset int
+}
+
+// From starts a typed query on table.
+func From[T any](runner QueryRunner, table string) *Query[T] {
+	var zero T
+	t := runner.Table(table)
+	if _, err := ModelOf(reflect.TypeOf(zero)); err == nil {
+		// scope by soft delete marker of T
+		t = t.Model(zero)
+	}
+
+	return &Query[T]{
+		runner: runner,
+		table:  table,
+		stmt:   t,
+	}
+}
+
+// Stmt returns underlying untyped statement.
+func (q *Query[T]) Stmt() StmtAggregate {
+	return q.stmt
+}
+
+func (q *Query[T]) condition() StmtCondition {
+	c, ok := q.stmt.(StmtCondition)
+	if !ok {
+		panic(fmt.Sprintf("cannot add condition to %T", q.stmt))
+	}
+	return c
+}
+
+func (q *Query[T]) Where(condition string, bind ...interface{}) *Query[T] {
+	q.stmt = q.condition().Where(condition, bind...)
+	return q
+}
+
+func (q *Query[T]) WhereEq(column string, value interface{}) *Query[T] {
+	q.stmt = q.condition().WhereEq(column, value)
+	return q
+}
+
+func (q *Query[T]) WhereIn(column string, values ...interface{}) *Query[T] {
+	q.stmt = q.condition().WhereIn(column, values...)
+	return q
+}
+
+func (q *Query[T]) WhereLike(column, pattern string) *Query[T] {
+	q.stmt = q.condition().WhereLike(column, pattern)
+	return q
+}
+
+func (q *Query[T]) WhereJSON(column, path string, value interface{}) *Query[T] {
+	q.stmt = q.condition().WhereJSON(column, path, value)
+	return q
+}
+
+func (q *Query[T]) WithDeleted() *Query[T] {
+	q.stmt = q.condition().WithDeleted()
+	return q
+}
+
+func (q *Query[T]) OnlyDeleted() *Query[T] {
+	q.stmt = q.condition().OnlyDeleted()
+	return q
+}
+
+func (q *Query[T]) OrderBy(columns ...string) *Query[T] {
+	q.stmt = q.stmt.OrderBy(columns...)
+	return q
+}
+
+func (q *Query[T]) LimitOffset(limit, offset int) *Query[T] {
+	q.limit = limit
+	q.offset = offset
+	q.stmt = q.stmt.LimitOffset(limit, offset)
+	return q
+}
+
+func (q *Query[T]) All(ctx context.Context) ([]T, error) {
+	rows, err := q.stmt.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []T{}
+	err = rows.ScanAll(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (q *Query[T]) Iter(ctx context.Context) iter.Seq2[T, error] {
+	return Iter[T](ctx, q.stmt)
+}
+
+// Each walks rows in batches, see StmtAggregate.Each.
+func (q *Query[T]) Each(ctx context.Context, batchSize int, fn func(batch []T) error) error {
+	buf := []T{}
+	return q.stmt.Each(ctx, batchSize, &buf, func(batch interface{}) error {
+		return fn(batch.([]T))
+	})
+}
+
+// Paginate fetches a page after the cursor, see StmtAggregate.Paginate.
+func (q *Query[T]) Paginate(ctx context.Context, after Cursor, limit int) ([]T, Cursor, error) {
+	result := []T{}
+	next, err := q.stmt.Paginate(ctx, after, limit, &result)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, next, nil
+}
+
+// Page fetches rows of the page, see StmtAggregate.Page.
+func (q *Query[T]) Page(ctx context.Context, page, perPage int) ([]T, PageInfo, error) {
+	result := []T{}
+	info, err := q.stmt.Page(ctx, page, perPage, &result)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return result, info, nil
+}
+
+// First returns the first row, or sql.ErrNoRows.
+func (q *Query[T]) First(ctx context.Context) (T, error) {
+	var zero T
+
+	// Row.ScanRow cannot tell whether the row exists, so fetch as rows
+	q.stmt = q.stmt.LimitOffset(1, q.offset)
+	result, err := q.All(ctx)
+	q.stmt = q.stmt.LimitOffset(q.limit, q.offset)
+	if err != nil {
+		return zero, err
+	}
+	if len(result) == 0 {
+		return zero, sql.ErrNoRows
+	}
+
+	return result[0], nil
+}
+
+// Get returns the row identified by primary keys in the order of fields
+// of T, or sql.ErrNoRows.
+func (q *Query[T]) Get(ctx context.Context, pk ...interface{}) (T, error) {
+	var zero T
+
+	m, err := ModelOf(reflect.TypeOf(zero))
+	if err != nil {
+		return zero, err
+	}
+
+	pks := m.PrimaryKeys()
+	if len(pks) == 0 || len(pks) != len(pk) {
+		return zero, fmt.Errorf(`%s has %d primary keys, but %d values given`, m.Type, len(pks), len(pk))
+	}
+
+	// keep q reusable
+	byKey := *q
+	byKey.stmt = q.stmt.Clone()
+	for i, f := range pks {
+		byKey.WhereEq(f.Column, pk[i])
+	}
+
+	return byKey.First(ctx)
+}
+
+// Insert creates rows. Autoincrement keys are filled into values.
+func (q *Query[T]) Insert(ctx context.Context, values ...T) error {
+	params := make([]interface{}, 0, len(values))
+	for i := range values {
+		params = append(params, &values[i])
+	}
+
+	return q.runner.Table(q.table).Create(ctx, params...)
+}
@@ -4,19 +4,35 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"fmt"
+	"log"
 	"os"
-	"reflect"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/acidlemon/aqua"
 	"github.com/jinzhu/gorm"
 )
 
 type db struct {
-	root    *gorm.DB
-	session *gorm.DB
+	root     *gorm.DB
+	dialect  aqua.Dialect
+	debug    bool
+	scanMode aqua.ScanMode
+	time     aqua.TimePolicy
+	clock    func() time.Time
+	tx       *tx // set inside a transaction
+}
+
+// tx is a db inside a transaction
+type tx struct {
+	*db
+}
+
+// *sql.DB and *sql.Tx
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 func init() {
@@ -24,43 +40,74 @@ func init() {
 }
 
 func Open(driver, path string) (aqua.DB, error) {
+	dialect, err := aqua.GetDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
 	d, err := gorm.Open(driver, path)
 	if err != nil {
 		return nil, err
 	}
 
+	if v, ok := dialect.(aqua.ServerVersioned); ok {
+		var version string
+		err = d.DB().QueryRow(v.VersionQuery()).Scan(&version)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		dialect = v.WithVersion(version)
+	}
+
+	debug := false
 	envval := os.Getenv("AQUA_DEBUG")
 	val, err := strconv.Atoi(envval)
 	if err == nil && val != 0 {
+		// gorm logs queries of GetProvider(), and trace those of aqua
 		d.LogMode(true)
+		debug = true
 	}
 
-	envval = os.Getenv("AQUA_GORM_ENABLE_AUTO_TIMESTAMP")
-	val, err = strconv.Atoi(envval)
-	if err == nil && val != 0 {
+	// timestamps are given by autocreate/autoupdate fields, not by gorm
+	d.Callback().Create().Remove("gorm:update_time_stamp")
+	d.Callback().Update().Remove("gorm:update_time_stamp")
 
-	} else {
-		d.Callback().Create().Remove("gorm:update_time_stamp")
-		d.Callback().Update().Remove("gorm:update_time_stamp")
-	}
-
-	return &db{root: d}, nil
+	return &db{root: d, dialect: dialect, debug: debug}, nil
 }
 
 func (db *db) GetProvider() interface{} {
 	return db.root
 }
 
+func (db *db) Dialect() aqua.Dialect {
+	return db.dialect
+}
+
 func (_db *db) Begin(ctx context.Context, opts *sql.TxOptions) (aqua.Tx, error) {
-	tx := _db.root.Begin()
-	result := &db{
-		root: tx,
-	}
+	root := _db.root.Begin()
+	result := &tx{&db{
+		root:     root,
+		dialect:  _db.dialect,
+		debug:    _db.debug,
+		scanMode: _db.scanMode,
+		time:     _db.time,
+		clock:    _db.clock,
+	}}
+	result.db.tx = result
 
 	return result, nil
 }
 
-func (db *db) Commit() error {
+// runner returns the Tx inside a transaction, otherwise the DB
+func (db *db) runner() aqua.QueryRunner {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db
+}
+
+func (db *tx) Commit() error {
 	db.root.Commit()
 	errs := db.root.GetErrors()
 	if len(errs) > 0 {
@@ -68,7 +115,7 @@ func (db *db) Commit() error {
 	}
 	return nil
 }
-func (db *db) Rollback() error {
+func (db *tx) Rollback() error {
 	db.root.Rollback()
 	errs := db.root.GetErrors()
 	if len(errs) > 0 {
@@ -97,157 +144,76 @@ func (db *db) SetMaxOpenConns(conn int) {
 	db.root.DB().SetMaxOpenConns(conn)
 }
 
-func (db *db) Table(name string) aqua.StmtTable {
-	db.session = db.root.Table(name)
-	return db
-}
-
-func (db *db) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return db.root.CommonDB().Exec(query, args...)
-}
-
-func (db *db) Create(ctx context.Context, param ...interface{}) error {
-	// TODO waiting support bulk insert
-	for _, v := range param {
-		db.session = db.session.Create(v)
-	}
-	return nil
-}
-func (db *db) Update(ctx context.Context, param interface{}) error {
-	v := reflect.ValueOf(param)
-	if v.Kind() == reflect.Map {
-		db.session = db.session.Updates(param)
-	} else {
-		// TODO update using existing structパターンで
-		// SET id=? WHERE id=?なクエリがでて気持ち悪いのをどうにかしたい
-		db.session = db.session.Model(param).Update(param)
-	}
-
-	errs := db.session.GetErrors()
-	if len(errs) > 0 {
-		return errs[0]
-	}
-	return nil
-}
-func (db *db) Delete(ctx context.Context, param interface{}) error {
-	db.session.Delete(param)
-	return nil
-}
-
-func (db *db) Join(table, condition string) aqua.StmtTable {
-	db.session = db.session.Joins(fmt.Sprintf("INNER JOIN %s ON %s", table, condition))
-	return db
-}
-
-func (db *db) LeftJoin(table, condition string) aqua.StmtTable {
-	db.session = db.session.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", table, condition))
-	return db
+func (db *db) SetScanMode(mode aqua.ScanMode) {
+	db.scanMode = mode
 }
 
-func (db *db) RightJoin(table, condition string) aqua.StmtTable {
-	db.session = db.session.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", table, condition))
-	return db
+func (db *db) SetTimePolicy(p aqua.TimePolicy) {
+	db.time = p
 }
 
-func (db *db) Select(columns ...string) aqua.StmtTable {
-	db.session = db.session.Select(strings.Join(columns, ", "))
-	return db
+func (db *db) SetClock(now func() time.Time) {
+	db.clock = now
 }
 
-func (db *db) Where(condition string, bind ...interface{}) aqua.StmtCondition {
-	if len(bind) == 1 {
-		t := reflect.TypeOf(bind[0])
-		//pp.Print(t)
-		if t.Kind() == reflect.Slice {
-			db.session = db.session.Where(condition, bind[0].([]interface{})...)
-		} else {
-			db.session = db.session.Where(condition, bind)
-		}
-	} else {
-		db.session = db.session.Where(condition, bind...)
+func (db *db) now() time.Time {
+	if db.clock == nil {
+		return time.Now()
 	}
-
-	return db
+	return db.clock()
 }
 
-func (db *db) WhereEq(column string, value interface{}) aqua.StmtCondition {
-	if value == nil {
-		db.session = db.session.Where(fmt.Sprintf("%s IS NULL", column))
-	} else {
-		db.session = db.session.Where(fmt.Sprintf("%s = ?", column), value)
-	}
-	return db
-}
-
-func (db *db) WhereIn(column string, values ...interface{}) aqua.StmtCondition {
-	if len(values) == 1 {
-		db.session = db.session.Where(fmt.Sprintf("%s in (?)", column), values...)
-	} else {
-		db.session = db.session.Where(fmt.Sprintf("%s in (?)", column), values)
+func (db *db) Table(name string) aqua.StmtTable {
+	return &stmt{
+		db:       db,
+		table:    name,
+		scanMode: db.scanMode,
+		model:    aqua.TableModel(name),
 	}
-	return db
 }
 
-func (db *db) WhereBetween(column string, a, b interface{}) aqua.StmtCondition {
-	db.session = db.session.Where(fmt.Sprintf("%s between ? and ?", column), a, b)
-	return db
-}
-
-func (db *db) WhereLike(column, pattern string) aqua.StmtCondition {
-	db.session = db.session.Where(fmt.Sprintf("%s like ?", column), pattern)
-	return db
-}
-
-func (db *db) All(ctx context.Context) (aqua.Rows, error) {
-	rs := &rows{
-		db: db,
-	}
-	return rs, nil
+func (db *db) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.exec(ctx, query, args)
 }
 
-func (db *db) Count(ctx context.Context) (int, error) {
-	var cnt int
-	db.session.Count(&cnt)
-	if errs := db.session.GetErrors(); len(errs) != 0 {
-		return 0, errs[len(errs)-1]
-	}
-
-	return cnt, nil
+func (db *db) conn() sqlConn {
+	return db.root.CommonDB().(sqlConn)
 }
 
-func (db *db) FetchColumn(ctx context.Context, column string) (aqua.Rows, error) {
-	db.session = db.session.Select(column)
-
-	rs := &rows{
-		db:    db,
-		pluck: true,
+func (db *db) trace(query string, args []interface{}) {
+	if db.debug {
+		log.Printf("[aqua] %s %v", query, args)
 	}
-	return rs, nil
 }
 
-func (db *db) Single(ctx context.Context) (aqua.Row, error) {
-	db.session = db.session.Limit(1)
-
-	r := &row{
-		session: db.session,
+// encode converts args of types registered by aqua.RegisterType and
+// normalizes times
+func (db *db) encode(args []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(args))
+	for _, a := range args {
+		result = append(result, aqua.Encode(db.dialect.Name(), db.time.Bind(a)))
 	}
-	return r, nil
+	return result
 }
 
-func (db *db) GroupBy(groups ...string) aqua.StmtAggregate {
-	db.session = db.session.Group(strings.Join(groups, ","))
-	return db
+// exec and query take `?` style query and rebind it for the dialect
+func (db *db) exec(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	query = aqua.Rebind(db.dialect, query)
+	args = db.encode(args)
+	db.trace(query, args)
+	return db.conn().ExecContext(ctx, query, args...)
 }
 
-func (db *db) OrderBy(orders ...string) aqua.StmtAggregate {
-	db.session = db.session.Order(strings.Join(orders, ","))
-	return db
+func (db *db) query(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+	query = aqua.Rebind(db.dialect, query)
+	args = db.encode(args)
+	db.trace(query, args)
+	return db.conn().QueryContext(ctx, query, args...)
 }
 
-func (db *db) Having(string) aqua.StmtAggregate {
-	return db
-}
-
-func (db *db) LimitOffset(limit, offset int) aqua.StmtAggregate {
-	return db
+func (db *db) queryRow(ctx context.Context, query string, args []interface{}) *sql.Row {
+	query = aqua.Rebind(db.dialect, query)
+	args = db.encode(args)
+	db.trace(query, args)
+	return db.conn().QueryRowContext(ctx, query, args...)
 }
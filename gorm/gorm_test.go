@@ -14,8 +14,5 @@ func TestSuite(t *testing.T) {
 	// show debug output of gorm
 	os.Setenv("AQUA_DEBUG", "1")
 
-	// disable gorm:update_time_stamp callback
-	os.Setenv("AQUA_GORM_DISABLE_AUTO_TIMESTAMP", "1")
-
 	ts.Run()
 }
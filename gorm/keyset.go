@@ -0,0 +1,255 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/acidlemon/aqua"
+)
+
+// keyset builds the condition selecting rows after values in the order of
+// columns, as `a > ? OR (a = ? AND b > ?)` which every dialect accepts.
+// NULL sorts as smaller than any value, see nullOrder.
+func (s *stmt) keyset(columns []string, desc []bool, values []interface{}) clause {
+	ors := make([]string, 0, len(columns))
+	args := []interface{}{}
+	for i := range columns {
+		column := s.quote(columns[i])
+		var after string
+		var afterArgs []interface{}
+		switch {
+		case values[i] == nil && desc[i]:
+			// nothing sorts after NULL in descending order
+			continue
+		case values[i] == nil:
+			after = column + " IS NOT NULL"
+		case desc[i]:
+			after = fmt.Sprintf("(%s < ? OR %s IS NULL)", column, column)
+			afterArgs = []interface{}{values[i]}
+		default:
+			after = column + " > ?"
+			afterArgs = []interface{}{values[i]}
+		}
+
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				ands = append(ands, s.quote(columns[j])+" IS NULL")
+				continue
+			}
+			ands = append(ands, fmt.Sprintf("%s = ?", s.quote(columns[j])))
+			args = append(args, values[j])
+		}
+		ands = append(ands, after)
+		args = append(args, afterArgs...)
+
+		ors = append(ors, strings.Join(ands, " AND "))
+	}
+
+	switch len(ors) {
+	case 0:
+		return clause{sql: s.db.dialect.Bool(false)}
+	case 1:
+		return clause{sql: ors[0], args: args}
+	}
+	return clause{sql: "(" + strings.Join(ors, ") OR (") + ")", args: args}
+}
+
+// nullOrder returns the ORDER BY term sorting NULL of column as smaller
+// than any value, which is the default of sqlite and mysql but not postgres
+func (s *stmt) nullOrder(column string, desc bool) string {
+	if desc {
+		return s.quote(column) + " IS NULL ASC"
+	}
+	return s.quote(column) + " IS NULL DESC"
+}
+
+func (s *stmt) Each(ctx context.Context, batchSize int, dest interface{}, fn func(batch interface{}) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf(`batchSize should be positive, not %d`, batchSize)
+	}
+
+	container := reflect.ValueOf(dest)
+	if container.Kind() != reflect.Ptr || container.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf(`dest should be a pointer to slice, not %T`, dest)
+	}
+	container = container.Elem()
+
+	m, err := aqua.ModelOf(container.Type().Elem())
+	if err != nil {
+		return err
+	}
+	pks := m.PrimaryKeys()
+	if len(pks) == 0 {
+		return fmt.Errorf(`%s has no primary key`, m.Type)
+	}
+
+	columns := make([]string, 0, len(pks))
+	for _, f := range pks {
+		columns = append(columns, f.Column)
+	}
+	desc := make([]bool, len(pks))
+
+	var last []interface{}
+	for {
+		page := *s
+		page.orders = columns
+		if last != nil {
+			page.wheres = append(append([]clause{}, s.wheres...), s.keyset(columns, desc, last))
+		}
+
+		query, args := page.selectSQL(s.columns, batchSize, 0)
+		sqlRows, err := s.db.query(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		container.Set(reflect.MakeSlice(container.Type(), 0, batchSize))
+		err = appendRows(s.hookContext(ctx), sqlRows, container, false, s.scanOptions())
+		sqlRows.Close()
+		if err != nil {
+			return err
+		}
+
+		n := container.Len()
+		if n == 0 {
+			return nil
+		}
+
+		err = fn(container.Interface())
+		if err != nil {
+			return err
+		}
+
+		if n < batchSize {
+			return nil
+		}
+
+		v := indirect(container.Index(n - 1))
+		last = make([]interface{}, 0, len(pks))
+		for _, f := range pks {
+			last = append(last, f.Value(v).Interface())
+		}
+	}
+}
+
+// sortKeys parses OrderBy terms into columns and directions
+func sortKeys(orders []string) ([]string, []bool, error) {
+	columns := make([]string, 0, len(orders))
+	desc := make([]bool, 0, len(orders))
+	for _, o := range orders {
+		fields := strings.Fields(o)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, nil, fmt.Errorf(`cannot paginate by "%s"`, o)
+		}
+
+		d := false
+		if len(fields) == 2 {
+			switch strings.ToUpper(fields[1]) {
+			case "ASC":
+			case "DESC":
+				d = true
+			default:
+				return nil, nil, fmt.Errorf(`cannot paginate by "%s"`, o)
+			}
+		}
+		columns = append(columns, fields[0])
+		desc = append(desc, d)
+	}
+
+	return columns, desc, nil
+}
+
+func (s *stmt) Paginate(ctx context.Context, after aqua.Cursor, limit int, dest interface{}) (aqua.Cursor, error) {
+	if limit <= 0 {
+		return "", fmt.Errorf(`limit should be positive, not %d`, limit)
+	}
+	if len(s.orders) == 0 {
+		return "", fmt.Errorf(`Paginate requires OrderBy`)
+	}
+
+	container := reflect.ValueOf(dest)
+	if container.Kind() != reflect.Ptr || container.Elem().Kind() != reflect.Slice {
+		return "", fmt.Errorf(`dest should be a pointer to slice, not %T`, dest)
+	}
+	container = container.Elem()
+
+	m, err := aqua.ModelOf(container.Type().Elem())
+	if err != nil {
+		return "", err
+	}
+
+	columns, desc, err := sortKeys(s.orders)
+	if err != nil {
+		return "", err
+	}
+	// primary keys break ties of sort keys
+	for _, f := range m.PrimaryKeys() {
+		if !contains(columns, f.Column) && !contains(columns, s.table+"."+f.Column) {
+			columns = append(columns, f.Column)
+			desc = append(desc, false)
+		}
+	}
+
+	fields := make([]*aqua.Field, 0, len(columns))
+	orders := make([]string, 0, len(columns))
+	for i, c := range columns {
+		name := c[strings.LastIndex(c, ".")+1:]
+		f := m.FieldByColumn(name)
+		if f == nil {
+			return "", fmt.Errorf(`%s has no field for sort key %s`, m.Type, c)
+		}
+		fields = append(fields, f)
+
+		if f.Nullable() {
+			orders = append(orders, s.nullOrder(c, desc[i]))
+		}
+		if desc[i] {
+			orders = append(orders, c+" DESC")
+		} else {
+			orders = append(orders, c)
+		}
+	}
+
+	page := *s
+	page.orders = orders
+	if after != "" {
+		values, err := after.Values()
+		if err != nil {
+			return "", err
+		}
+		if len(values) != len(columns) {
+			return "", fmt.Errorf(`cursor does not match sort keys`)
+		}
+		page.wheres = append(append([]clause{}, s.wheres...), s.keyset(columns, desc, values))
+	}
+
+	// fetch one more row to know whether next page exists
+	query, args := page.selectSQL(s.columns, limit+1, 0)
+	sqlRows, err := s.db.query(ctx, query, args)
+	if err != nil {
+		return "", err
+	}
+	defer sqlRows.Close()
+
+	container.Set(reflect.MakeSlice(container.Type(), 0, limit+1))
+	err = appendRows(s.hookContext(ctx), sqlRows, container, false, s.scanOptions())
+	if err != nil {
+		return "", err
+	}
+
+	if container.Len() <= limit {
+		return "", nil
+	}
+	container.Set(container.Slice(0, limit))
+
+	v := indirect(container.Index(limit - 1))
+	values := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		values = append(values, f.Value(v).Interface())
+	}
+
+	return aqua.EncodeCursor(values)
+}
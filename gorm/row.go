@@ -1,31 +1,48 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 
-	"github.com/jinzhu/gorm"
+	"github.com/acidlemon/aqua"
 )
 
 type row struct {
-	session *gorm.DB
+	ctx   context.Context
+	db    *db
+	opts  aqua.ScanOptions
+	query string
+	args  []interface{}
 }
 
 func (r *row) Scan(dest ...interface{}) error {
-	rows, err := r.session.Model(dest).Rows()
+	rows, err := r.db.query(r.ctx, r.query, r.args)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
 	if rows.Next() {
-		return rows.Scan(dest...)
+		return scanValues(rows, dest, r.opts)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
 	return sql.ErrNoRows
 }
 
+// ScanRow leaves dest untouched when no row matched
 func (r *row) ScanRow(dest interface{}) error {
-	r.session.Model(dest).Scan(dest)
+	rows, err := r.db.query(r.ctx, r.query, r.args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scan(r.ctx, rows, dest, r.opts)
+	}
 
-	return nil
+	return rows.Err()
 }
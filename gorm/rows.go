@@ -1,102 +1,145 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+
+	"github.com/acidlemon/aqua"
 )
 
 type rows struct {
+	ctx     context.Context // carries runner for hooks
 	db      *db
 	pluck   bool
+	opts    aqua.ScanOptions
+	checked bool // columns are same for every row, check only the first
 	sqlRows *sql.Rows
 }
 
 func (r *rows) Scan(dest ...interface{}) error {
-	if r.sqlRows == nil {
-		sqlRows, err := r.db.session.Rows()
+	return scanValues(r.sqlRows, dest, r.opts)
+}
+
+func (r *rows) ScanRow(dest interface{}) error {
+	if r.pluck {
+		return scanValues(r.sqlRows, []interface{}{dest}, r.opts)
+	}
+
+	opts := r.opts
+	if r.checked {
+		opts.Mode = aqua.ScanLoose
+	}
+	r.checked = true
+	return scan(r.ctx, r.sqlRows, dest, opts)
+}
+
+// scan scans the current row into a struct or map[string]interface{}, and
+// calls AfterFind hook of the struct
+func scan(ctx context.Context, sqlRows *sql.Rows, dest interface{}, opts aqua.ScanOptions) error {
+	if m, ok := dest.(*map[string]interface{}); ok {
+		err := aqua.ScanMap(sqlRows, m)
 		if err != nil {
 			return err
 		}
-		r.sqlRows = sqlRows
+		for k, v := range *m {
+			opts.Time.Normalize(&v)
+			(*m)[k] = v
+		}
+		return nil
+	}
+	err := aqua.ScanStructWith(sqlRows, dest, opts)
+	if err != nil {
+		return err
 	}
 
-	return r.sqlRows.Scan(dest...)
+	// **T
+	v := reflect.ValueOf(dest)
+	for v.Elem().Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return aqua.CallHook(ctx, aqua.HookAfterFind, v.Interface())
 }
 
-func (r *rows) ScanAll(dest interface{}) error {
-	if !r.pluck {
-		r.db.session.Model(dest).Scan(dest)
-		return nil
+// scanValues scans into dest like sql.Rows, decoding registered types and
+// normalizing times
+func scanValues(sqlRows *sql.Rows, dest []interface{}, opts aqua.ScanOptions) error {
+	targets := make([]interface{}, 0, len(dest))
+	for _, d := range dest {
+		targets = append(targets, aqua.Decoder(opts.Dialect, d))
 	}
 
-	// copy from gorm scan
-	sqlRows, err := r.db.session.Rows()
+	err := sqlRows.Scan(targets...)
 	if err != nil {
 		return err
 	}
-	defer sqlRows.Close()
 
+	for _, d := range dest {
+		opts.Time.Normalize(d)
+	}
+	return nil
+}
+
+func (r *rows) ScanAll(dest interface{}) error {
 	container := reflect.Indirect(reflect.ValueOf(dest))
 	if container.Kind() != reflect.Slice {
 		return fmt.Errorf(`dest should be a slice, not %s`, container.Kind())
 	}
+	container.Set(reflect.MakeSlice(container.Type(), 0, 0))
 
-	for sqlRows.Next() {
-		elem := reflect.New(container.Type().Elem()).Interface()
-		sqlRows.Scan(elem)
-		container.Set(reflect.Append(container, reflect.ValueOf(elem).Elem()))
+	opts := r.opts
+	if r.checked {
+		opts.Mode = aqua.ScanLoose
 	}
-
-	return nil
+	r.checked = true
+	return appendRows(r.ctx, r.sqlRows, container, r.pluck, opts)
 }
 
-func (r *rows) Close() error {
-	if r.sqlRows == nil {
-		sqlRows, err := r.db.session.Rows()
+// appendRows scans remaining sqlRows and appends them to container, checking
+// columns of the first row with opts.Mode
+func appendRows(ctx context.Context, sqlRows *sql.Rows, container reflect.Value, pluck bool, opts aqua.ScanOptions) error {
+	elemType := container.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	for sqlRows.Next() {
+		elem := reflect.New(elemType)
+
+		var err error
+		if pluck {
+			err = scanValues(sqlRows, []interface{}{elem.Interface()}, opts)
+		} else {
+			err = scan(ctx, sqlRows, elem.Interface(), opts)
+			opts.Mode = aqua.ScanLoose
+		}
 		if err != nil {
 			return err
 		}
 
-		r.sqlRows = sqlRows
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		container.Set(reflect.Append(container, elem))
 	}
 
+	return sqlRows.Err()
+}
+
+func (r *rows) Close() error {
 	return r.sqlRows.Close()
 }
 
 func (r *rows) Columns() ([]string, error) {
-	if r.sqlRows == nil {
-		sqlRows, err := r.db.session.Rows()
-		if err != nil {
-			return nil, err
-		}
-
-		r.sqlRows = sqlRows
-	}
-
 	return r.sqlRows.Columns()
 }
-func (r *rows) Err() error {
-	if r.sqlRows == nil {
-		sqlRows, err := r.db.session.Rows()
-		if err != nil {
-			return err
-		}
-
-		r.sqlRows = sqlRows
-	}
 
+func (r *rows) Err() error {
 	return r.sqlRows.Err()
 }
-func (r *rows) Next() bool {
-	if r.sqlRows == nil {
-		sqlRows, err := r.db.session.Rows()
-		if err != nil {
-			return false
-		}
-
-		r.sqlRows = sqlRows
-	}
 
+func (r *rows) Next() bool {
 	return r.sqlRows.Next()
 }
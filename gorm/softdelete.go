@@ -0,0 +1,108 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/acidlemon/aqua"
+)
+
+// deletedScope selects rows by soft delete marker
+type deletedScope int
+
+const (
+	excludeDeleted deletedScope = iota
+	withDeleted
+	onlyDeleted
+)
+
+func (s *stmt) Model(v interface{}) aqua.StmtTable {
+	m, err := aqua.ModelOf(reflect.TypeOf(v))
+	if err != nil {
+		panic(err.Error())
+	}
+	s.model = m
+	return s
+}
+
+func (s *stmt) WithDeleted() aqua.StmtCondition {
+	s.deleted = withDeleted
+	return s
+}
+
+func (s *stmt) OnlyDeleted() aqua.StmtCondition {
+	s.deleted = onlyDeleted
+	return s
+}
+
+// scoped appends the condition on soft delete marker of m to wheres
+func (s *stmt) scoped(m *aqua.Model, wheres []clause) []clause {
+	if m == nil || s.deleted == withDeleted {
+		return wheres
+	}
+	f := m.SoftDelete()
+	if f == nil {
+		return wheres
+	}
+
+	column := s.quote(s.table + "." + f.Column)
+	deleted := s.deleted == onlyDeleted
+	var cond string
+	switch f.Type.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		// unix seconds, 0 for alive
+		cond = column + " = 0"
+		if deleted {
+			cond = column + " <> 0"
+		}
+	default:
+		cond = column + " IS NULL"
+		if deleted {
+			cond = column + " IS NOT NULL"
+		}
+	}
+
+	return append(append([]clause{}, wheres...), clause{sql: cond})
+}
+
+func (s *stmt) HardDelete(ctx context.Context, v interface{}) error {
+	s.hard = true
+	defer func() { s.hard = false }()
+
+	return s.Delete(ctx, v)
+}
+
+// softDelete marks rows matching wheres deleted by f, and also sets it to
+// the struct v when addressable
+func (s *stmt) softDelete(ctx context.Context, param interface{}, v reflect.Value, m *aqua.Model, wheres []clause) error {
+	f := m.SoftDelete()
+	now := s.db.now()
+	arg, err := f.TimeArg(now)
+	if err != nil {
+		return err
+	}
+
+	// deleted rows are not deleted again
+	scope := s.deleted
+	s.deleted = excludeDeleted
+	wheres = s.scoped(m, wheres)
+	s.deleted = scope
+
+	where, args := whereSQL(wheres)
+	query := fmt.Sprintf("UPDATE %s SET %s = ?%s", s.quote(s.table), s.quote(f.Column), where)
+	args = append([]interface{}{arg}, args...)
+	if s.hasReturn {
+		_, err = s.execReturning(ctx, query, args, param)
+	} else {
+		_, err = s.db.exec(ctx, query, args)
+	}
+	if err != nil {
+		return err
+	}
+
+	if param != nil && v.CanAddr() {
+		return f.SetTime(v, now)
+	}
+	return nil
+}
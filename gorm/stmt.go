@@ -0,0 +1,436 @@
+package gorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/acidlemon/aqua"
+)
+
+type clause struct {
+	sql  string
+	args []interface{}
+}
+
+type stmt struct {
+	db      *db
+	table   string
+	joins   []string
+	columns []string
+	wheres  []clause
+	groups  []string
+	having  string
+	orders  []string
+	limit   int
+	offset  int
+
+	returning []string
+	hasReturn bool
+	into      interface{}
+	allowFull bool
+	scanMode  aqua.ScanMode
+	before    interface{} // image of the struct to update
+	only      []string
+	omit      []string
+
+	model   *aqua.Model // scopes by soft delete marker
+	deleted deletedScope
+	hard    bool
+}
+
+func (s *stmt) Clone() aqua.StmtAggregate {
+	c := *s
+	c.joins = append([]string{}, s.joins...)
+	c.columns = append([]string{}, s.columns...)
+	c.wheres = append([]clause{}, s.wheres...)
+	c.groups = append([]string{}, s.groups...)
+	c.orders = append([]string{}, s.orders...)
+	c.returning = append([]string{}, s.returning...)
+	c.only = append([]string{}, s.only...)
+	c.omit = append([]string{}, s.omit...)
+	return &c
+}
+
+func (s *stmt) scanOptions() aqua.ScanOptions {
+	return aqua.ScanOptions{
+		Mode:    s.scanMode,
+		Dialect: s.db.dialect.Name(),
+		Time:    s.db.time,
+	}
+}
+
+// hookContext carries the runner of the statement to hooks
+func (s *stmt) hookContext(ctx context.Context) context.Context {
+	return aqua.WithRunner(ctx, s.db.runner())
+}
+
+// hook calls hook of struct value v
+func (s *stmt) hook(ctx context.Context, hook aqua.Hook, v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	return aqua.CallHook(s.hookContext(ctx), hook, v.Interface())
+}
+
+func (s *stmt) quote(ident string) string {
+	return s.db.dialect.Quote(ident)
+}
+
+func (s *stmt) quoteAll(idents []string) []string {
+	result := make([]string, 0, len(idents))
+	for _, v := range idents {
+		result = append(result, s.quote(strings.TrimSpace(v)))
+	}
+	return result
+}
+
+// quoteOrder quotes "column" or "column DESC"
+func (s *stmt) quoteOrder(term string) string {
+	fields := strings.Fields(term)
+	if len(fields) == 2 {
+		dir := strings.ToUpper(fields[1])
+		if dir == "ASC" || dir == "DESC" {
+			return s.quote(fields[0]) + " " + dir
+		}
+	}
+	return s.quote(strings.TrimSpace(term))
+}
+
+func (s *stmt) where(cond string, args ...interface{}) {
+	sql, args := expand(cond, args)
+	s.wheres = append(s.wheres, clause{sql: sql, args: args})
+}
+
+// expand replaces `?` bound to a slice with `?, ?, ...` and flattens the slice
+func expand(cond string, args []interface{}) (string, []interface{}) {
+	buf := strings.Builder{}
+	result := make([]interface{}, 0, len(args))
+	i := 0
+	var quote rune
+	for _, c := range cond {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && i < len(args):
+			arg := args[i]
+			i++
+			if !isList(arg) {
+				result = append(result, arg)
+				break
+			}
+
+			v := reflect.ValueOf(arg)
+			if v.Len() == 0 {
+				buf.WriteString("NULL")
+				continue
+			}
+			for j := 0; j < v.Len(); j++ {
+				if j > 0 {
+					buf.WriteString(", ")
+				}
+				buf.WriteRune('?')
+				result = append(result, v.Index(j).Interface())
+			}
+			continue
+		}
+		buf.WriteRune(c)
+	}
+	result = append(result, args[i:]...)
+
+	return buf.String(), result
+}
+
+func isList(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+
+	t := reflect.TypeOf(arg)
+	if aqua.RegisteredType(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+func (s *stmt) from() string {
+	sql := "FROM " + s.quote(s.table)
+	for _, j := range s.joins {
+		sql += " " + j
+	}
+	return sql
+}
+
+func whereSQL(wheres []clause) (string, []interface{}) {
+	if len(wheres) == 0 {
+		return "", nil
+	}
+
+	conds := make([]string, 0, len(wheres))
+	args := []interface{}{}
+	for _, w := range wheres {
+		conds = append(conds, "("+w.sql+")")
+		args = append(args, w.args...)
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (s *stmt) groupSQL() string {
+	sql := ""
+	if len(s.groups) > 0 {
+		sql += " GROUP BY " + strings.Join(s.quoteAll(s.groups), ", ")
+	}
+	if s.having != "" {
+		sql += " HAVING " + s.having
+	}
+	return sql
+}
+
+func (s *stmt) selectSQL(columns []string, limit, offset int) (string, []interface{}) {
+	cols := "*"
+	if len(columns) > 0 {
+		cols = strings.Join(s.quoteAll(columns), ", ")
+	}
+
+	where, args := whereSQL(s.scoped(s.model, s.wheres))
+	sql := "SELECT " + cols + " " + s.from() + where + s.groupSQL()
+	if len(s.orders) > 0 {
+		orders := make([]string, 0, len(s.orders))
+		for _, o := range s.orders {
+			orders = append(orders, s.quoteOrder(o))
+		}
+		sql += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	if lo := s.db.dialect.LimitOffset(limit, offset); lo != "" {
+		sql += " " + lo
+	}
+
+	return sql, args
+}
+
+func (s *stmt) Join(table, condition string) aqua.StmtTable {
+	s.joins = append(s.joins, fmt.Sprintf("INNER JOIN %s ON %s", s.quote(table), condition))
+	return s
+}
+
+func (s *stmt) LeftJoin(table, condition string) aqua.StmtTable {
+	s.joins = append(s.joins, fmt.Sprintf("LEFT JOIN %s ON %s", s.quote(table), condition))
+	return s
+}
+
+func (s *stmt) RightJoin(table, condition string) aqua.StmtTable {
+	s.joins = append(s.joins, fmt.Sprintf("RIGHT JOIN %s ON %s", s.quote(table), condition))
+	return s
+}
+
+func (s *stmt) Select(columns ...string) aqua.StmtTable {
+	s.columns = columns
+	return s
+}
+
+func (s *stmt) Where(condition string, bind ...interface{}) aqua.StmtCondition {
+	if len(bind) == 1 {
+		if list, ok := bind[0].([]interface{}); ok {
+			bind = list
+		}
+	}
+	s.where(condition, bind...)
+
+	return s
+}
+
+func (s *stmt) WhereEq(column string, value interface{}) aqua.StmtCondition {
+	if value == nil {
+		s.where(fmt.Sprintf("%s IS NULL", s.quote(column)))
+	} else {
+		s.where(fmt.Sprintf("%s = ?", s.quote(column)), value)
+	}
+	return s
+}
+
+func (s *stmt) WhereJSON(column, path string, value interface{}) aqua.StmtCondition {
+	expr := s.db.dialect.JSONExtract(column, path)
+	switch {
+	case value == nil:
+		s.where(fmt.Sprintf("%s IS NULL", expr))
+	case isNumber(value):
+		s.where(fmt.Sprintf("%s = ?", s.db.dialect.JSONNumber(column, path)), value)
+	default:
+		s.where(fmt.Sprintf("%s = ?", expr), jsonText(value))
+	}
+	return s
+}
+
+func isNumber(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// jsonText returns value in text as JSONExtract gives
+func jsonText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.Trim(string(b), `"`)
+}
+
+func (s *stmt) WhereIn(column string, values ...interface{}) aqua.StmtCondition {
+	if len(values) == 1 {
+		s.where(fmt.Sprintf("%s IN (?)", s.quote(column)), values...)
+	} else {
+		s.where(fmt.Sprintf("%s IN (?)", s.quote(column)), values)
+	}
+	return s
+}
+
+func (s *stmt) WhereBetween(column string, a, b interface{}) aqua.StmtCondition {
+	s.where(fmt.Sprintf("%s BETWEEN ? AND ?", s.quote(column)), a, b)
+	return s
+}
+
+func (s *stmt) WhereLike(column, pattern string) aqua.StmtCondition {
+	s.where(fmt.Sprintf("%s LIKE ?", s.quote(column)), pattern)
+	return s
+}
+
+func (s *stmt) GroupBy(groups ...string) aqua.StmtAggregate {
+	s.groups = groups
+	return s
+}
+
+func (s *stmt) OrderBy(orders ...string) aqua.StmtAggregate {
+	s.orders = orders
+	return s
+}
+
+func (s *stmt) Having(condition string) aqua.StmtAggregate {
+	s.having = condition
+	return s
+}
+
+func (s *stmt) LimitOffset(limit, offset int) aqua.StmtAggregate {
+	s.limit = limit
+	s.offset = offset
+	return s
+}
+
+func (s *stmt) All(ctx context.Context) (aqua.Rows, error) {
+	query, args := s.selectSQL(s.columns, s.limit, s.offset)
+	sqlRows, err := s.db.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &rows{
+		ctx:     s.hookContext(ctx),
+		db:      s.db,
+		opts:    s.scanOptions(),
+		sqlRows: sqlRows,
+	}
+	return rs, nil
+}
+
+func (s *stmt) Count(ctx context.Context) (int, error) {
+	where, args := whereSQL(s.scoped(s.model, s.wheres))
+	query := "SELECT COUNT(*) " + s.from() + where
+	if len(s.groups) > 0 {
+		query = "SELECT COUNT(*) FROM (SELECT 1 " + s.from() + where + s.groupSQL() + ") aqua_count"
+	}
+
+	var cnt int
+	err := s.db.queryRow(ctx, query, args).Scan(&cnt)
+	if err != nil {
+		return 0, err
+	}
+
+	return cnt, nil
+}
+
+func (s *stmt) Page(ctx context.Context, page, perPage int, dest interface{}) (aqua.PageInfo, error) {
+	if page < 1 || perPage < 1 {
+		return aqua.PageInfo{}, fmt.Errorf(`invalid page %d of %d rows`, page, perPage)
+	}
+
+	container := reflect.ValueOf(dest)
+	if container.Kind() != reflect.Ptr || container.Elem().Kind() != reflect.Slice {
+		return aqua.PageInfo{}, fmt.Errorf(`dest should be a pointer to slice, not %T`, dest)
+	}
+	container = container.Elem()
+
+	total, err := s.Count(ctx)
+	if err != nil {
+		return aqua.PageInfo{}, err
+	}
+
+	query, args := s.selectSQL(s.columns, perPage, (page-1)*perPage)
+	sqlRows, err := s.db.query(ctx, query, args)
+	if err != nil {
+		return aqua.PageInfo{}, err
+	}
+	defer sqlRows.Close()
+
+	container.Set(reflect.MakeSlice(container.Type(), 0, perPage))
+	err = appendRows(s.hookContext(ctx), sqlRows, container, false, s.scanOptions())
+	if err != nil {
+		return aqua.PageInfo{}, err
+	}
+
+	return aqua.NewPageInfo(page, perPage, total), nil
+}
+
+func (s *stmt) FetchColumn(ctx context.Context, column string) (aqua.Rows, error) {
+	query, args := s.selectSQL([]string{column}, s.limit, s.offset)
+	sqlRows, err := s.db.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &rows{
+		ctx:     s.hookContext(ctx),
+		db:      s.db,
+		pluck:   true,
+		opts:    s.scanOptions(),
+		sqlRows: sqlRows,
+	}
+	return rs, nil
+}
+
+func (s *stmt) Single(ctx context.Context) (aqua.Row, error) {
+	query, args := s.selectSQL(s.columns, 1, s.offset)
+
+	r := &row{
+		ctx:   s.hookContext(ctx),
+		db:    s.db,
+		opts:  s.scanOptions(),
+		query: query,
+		args:  args,
+	}
+	return r, nil
+}
@@ -0,0 +1,640 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/acidlemon/aqua"
+)
+
+func (s *stmt) Returning(columns ...string) aqua.StmtReturning {
+	s.returning = columns
+	s.hasReturn = true
+	return s
+}
+
+func (s *stmt) Into(dest interface{}) aqua.StmtReturning {
+	s.into = dest
+	return s
+}
+
+// execReturning runs query with RETURNING clause and scans returned rows
+// into s.into, or the first returned row into target. It returns the number
+// of returned rows.
+func (s *stmt) execReturning(ctx context.Context, query string, args []interface{}, target interface{}, extra ...string) (int64, error) {
+	if !s.db.dialect.SupportsReturning() {
+		return 0, fmt.Errorf("%w: RETURNING is not available on %s", aqua.ErrUnsupported, s.db.dialect.Name())
+	}
+
+	columns := "*"
+	if len(s.returning) > 0 {
+		returning := append(append([]string{}, s.returning...), extra...)
+		columns = strings.Join(s.quoteAll(returning), ", ")
+	}
+
+	if s.into == nil {
+		t := reflect.TypeOf(target)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return 0, fmt.Errorf(`cannot scan returned row into %T, use Into()`, target)
+		}
+	}
+
+	sqlRows, err := s.db.query(ctx, query+" RETURNING "+columns, args)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlRows.Close()
+
+	if s.into != nil {
+		container := reflect.ValueOf(s.into).Elem()
+		n := container.Len()
+		err = appendRows(s.hookContext(ctx), sqlRows, container, false, s.scanOptions())
+		return int64(container.Len() - n), err
+	}
+
+	var n int64
+	for sqlRows.Next() {
+		if n == 0 {
+			err = scan(s.hookContext(ctx), sqlRows, target, s.scanOptions())
+			if err != nil {
+				return 0, err
+			}
+		}
+		n++
+	}
+	return n, sqlRows.Err()
+}
+
+func contains(list []string, v string) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stmt) Create(ctx context.Context, param ...interface{}) error {
+	err := s.resetInto()
+	if err != nil {
+		return err
+	}
+
+	// TODO waiting support bulk insert
+	for _, v := range param {
+		err = s.insert(ctx, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert creates a row of v between hooks
+func (s *stmt) insert(ctx context.Context, v interface{}) error {
+	rv := addressable(indirect(reflect.ValueOf(v)))
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return fmt.Errorf(`cannot create by nil %T`, v)
+	}
+	err := s.hook(ctx, aqua.HookBeforeCreate, rv)
+	if err != nil {
+		return err
+	}
+
+	err = s.insertRow(ctx, v, rv)
+	if err != nil {
+		return err
+	}
+	aqua.Track(v)
+
+	return s.hook(ctx, aqua.HookAfterCreate, rv)
+}
+
+func (s *stmt) insertRow(ctx context.Context, v interface{}, rv reflect.Value) error {
+	columns := []string{}
+	values := []interface{}{}
+	var auto *aqua.Field
+	if rv.Kind() == reflect.Map {
+		columns, values = s.mapColumns(rv)
+	} else {
+		m, err := aqua.ModelOf(rv.Type())
+		if err != nil {
+			return err
+		}
+		err = s.checkColumns(m)
+		if err != nil {
+			return err
+		}
+		rv, err = s.touch(m, rv, true)
+		if err != nil {
+			return err
+		}
+		for _, f := range m.Fields {
+			if f.ReadOnly {
+				continue
+			}
+			write, named := s.writes(f)
+			if f.IsZero(rv) && !named {
+				if f.AutoIncrement {
+					// leave it to the database
+					if auto == nil {
+						auto = f
+					}
+					continue
+				}
+				if f.OmitEmpty {
+					continue
+				}
+			}
+			if !write {
+				continue
+			}
+			columns = append(columns, f.Column)
+			values = append(values, f.Arg(rv))
+		}
+	}
+
+	binds := make([]string, 0, len(values))
+	args := []interface{}{}
+	for _, value := range values {
+		sql, a := bind(value)
+		binds = append(binds, sql)
+		args = append(args, a...)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", s.quote(s.table),
+		strings.Join(s.quoteAll(columns), ", "), strings.Join(binds, ", "))
+	if len(columns) == 0 {
+		query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", s.quote(s.table))
+	}
+
+	if s.hasReturn {
+		if auto != nil && len(s.returning) > 0 && !contains(s.returning, auto.Column) {
+			// autoincrement column is always filled by RETURNING
+			_, err := s.execReturning(ctx, query, args, v, auto.Column)
+			return err
+		}
+		_, err := s.execReturning(ctx, query, args, v)
+		return err
+	}
+
+	if auto == nil || !rv.CanAddr() {
+		_, err := s.db.exec(ctx, query, args)
+		return err
+	}
+
+	// fill autoincrement column
+	fv := auto.Value(rv)
+	if s.db.dialect.Name() == "postgres" {
+		// lib/pq gives no LastInsertId
+		query += " RETURNING " + s.quote(auto.Column)
+		return s.db.queryRow(ctx, query, args).Scan(fv.Addr().Interface())
+	}
+
+	result, err := s.db.exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return setInt(fv, id)
+}
+
+// touch sets current time to autocreate/autoupdate fields of rv, or of its
+// copy when rv is not addressable
+func (s *stmt) touch(m *aqua.Model, rv reflect.Value, create bool) (reflect.Value, error) {
+	now := s.db.now()
+	for _, f := range m.Fields {
+		if create {
+			if !(f.AutoCreate || f.AutoUpdate) || !f.IsZero(rv) {
+				continue
+			}
+		} else if !f.AutoUpdate {
+			continue
+		}
+
+		if !rv.CanAddr() {
+			c := reflect.New(rv.Type()).Elem()
+			c.Set(rv)
+			rv = c
+		}
+		err := f.SetTime(rv, now)
+		if err != nil {
+			return rv, err
+		}
+	}
+	return rv, nil
+}
+
+// indirect dereferences pointers, even pointer to pointer
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// addressable copies struct passed by value, so that hooks with pointer
+// receiver are called and their changes are written
+func addressable(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Struct || v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+func setInt(v reflect.Value, i int64) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(i))
+	default:
+		return fmt.Errorf(`cannot set last insert id to %s`, v.Type())
+	}
+	return nil
+}
+
+func (s *stmt) InsertFrom(ctx context.Context, columns []string, source aqua.StmtAggregate) (int64, error) {
+	src, ok := source.(*stmt)
+	if !ok {
+		return 0, fmt.Errorf(`source should be a statement of gorm provider, not %T`, source)
+	}
+
+	selectColumns := src.columns
+	if len(selectColumns) == 0 {
+		selectColumns = columns
+	}
+	query, args := src.selectSQL(selectColumns, src.limit, src.offset)
+
+	target := s.quote(s.table)
+	if len(columns) > 0 {
+		target += " (" + strings.Join(s.quoteAll(columns), ", ") + ")"
+	}
+
+	result, err := s.db.exec(ctx, fmt.Sprintf("INSERT INTO %s %s", target, query), args)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// bind returns a placeholder for v, or SQL of aqua.Expression
+func bind(v interface{}) (string, []interface{}) {
+	if e, ok := v.(aqua.Expression); ok {
+		return expand(e.SQL, e.Args)
+	}
+	return "?", []interface{}{v}
+}
+
+// mapColumns returns keys of map v restricted by Only/Omit, and the values
+func (s *stmt) mapColumns(v reflect.Value) ([]string, []interface{}) {
+	columns := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		c := k.String()
+		if contains(s.omit, c) || len(s.only) > 0 && !contains(s.only, c) {
+			continue
+		}
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	values := make([]interface{}, 0, len(columns))
+	for _, c := range columns {
+		values = append(values, v.MapIndex(reflect.ValueOf(c)).Interface())
+	}
+	return columns, values
+}
+
+func (s *stmt) Update(ctx context.Context, param interface{}) error {
+	err := s.resetInto()
+	if err != nil {
+		return err
+	}
+
+	v := addressable(indirect(reflect.ValueOf(param)))
+	if !v.IsValid() || v.Kind() == reflect.Ptr {
+		return fmt.Errorf(`cannot update by nil %T`, param)
+	}
+	err = s.hook(ctx, aqua.HookBeforeUpdate, v)
+	if err != nil {
+		return err
+	}
+
+	err = s.update(ctx, param, v)
+	if err != nil {
+		return err
+	}
+	aqua.Track(param)
+
+	return s.hook(ctx, aqua.HookAfterUpdate, v)
+}
+
+func (s *stmt) update(ctx context.Context, param interface{}, v reflect.Value) error {
+	sets := []string{}
+	args := []interface{}{}
+	wheres := append([]clause{}, s.wheres...)
+	scope := s.model
+	var version *aqua.Field
+
+	if v.Kind() == reflect.Map {
+		columns, values := s.mapColumns(v)
+		for i, c := range columns {
+			sql, a := bind(values[i])
+			sets = append(sets, fmt.Sprintf("%s = %s", s.quote(c), sql))
+			args = append(args, a...)
+		}
+	} else {
+		m, err := aqua.ModelOf(v.Type())
+		if err != nil {
+			return err
+		}
+		err = s.checkColumns(m)
+		if err != nil {
+			return err
+		}
+
+		// fields changed since loaded or before image
+		changes, tracked, err := m.Changes(v, s.before)
+		if err != nil {
+			return fmt.Errorf(`cannot update by %T: %w`, param, err)
+		}
+		if tracked && len(changes) == 0 && len(s.only) == 0 {
+			return nil
+		}
+		changed := map[*aqua.Field]bool{}
+		for _, f := range changes {
+			changed[f] = true
+		}
+
+		v, err = s.touch(m, v, false)
+		if err != nil {
+			return err
+		}
+		// registered model still scopes struct without the marker
+		if m.SoftDelete() != nil || scope == nil {
+			scope = m
+		}
+
+		// struct identifies the row by its keys, use map to update rows
+		// matching conditions of the builder
+		pkWheres, err := s.primaryKeyWheres(m, v)
+		if err != nil {
+			return fmt.Errorf(`cannot update by %T: %w`, param, err)
+		}
+		wheres = append(wheres, pkWheres...)
+
+		// optimistic locking
+		if f := m.Version(); f != nil {
+			version = f
+			wheres = append(wheres, clause{
+				sql:  fmt.Sprintf("%s = ?", s.quote(f.Column)),
+				args: []interface{}{f.Value(v).Interface()},
+			})
+			sets = append(sets, fmt.Sprintf("%s = %s + 1", s.quote(f.Column), s.quote(f.Column)))
+		}
+
+		for _, f := range m.Fields {
+			if f.ReadOnly || f.PrimaryKey || f == version {
+				continue
+			}
+			write, named := s.writes(f)
+			if !write {
+				continue
+			}
+			switch {
+			case named:
+				// written even if zero or unchanged
+			case tracked:
+				// changed zero value is written too
+				if !changed[f] && !f.AutoUpdate {
+					continue
+				}
+			case f.IsZero(v):
+				// NULL too, which may be just not loaded
+				continue
+			}
+			sql, a := bind(f.Arg(v))
+			sets = append(sets, fmt.Sprintf("%s = %s", s.quote(f.Column), sql))
+			args = append(args, a...)
+		}
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	err := s.guard("UPDATE", wheres)
+	if err != nil {
+		return err
+	}
+
+	where, whereArgs := whereSQL(s.scoped(scope, wheres))
+	query := fmt.Sprintf("UPDATE %s SET %s%s", s.quote(s.table), strings.Join(sets, ", "), where)
+	args = append(args, whereArgs...)
+
+	var affected int64
+	if s.hasReturn {
+		affected, err = s.execReturning(ctx, query, args, param)
+	} else {
+		var result sql.Result
+		result, err = s.db.exec(ctx, query, args)
+		if err == nil && version != nil {
+			affected, err = result.RowsAffected()
+		}
+	}
+	if err != nil || version == nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: %s of %s", aqua.ErrStaleObject, v.Type(), s.table)
+	}
+	if v.CanAddr() {
+		fv := version.Value(v)
+		switch fv.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			fv.SetUint(fv.Uint() + 1)
+		default:
+			fv.SetInt(fv.Int() + 1)
+		}
+	}
+	return nil
+}
+
+func (s *stmt) Increment(ctx context.Context, column string, delta int) error {
+	return s.Update(ctx, map[string]interface{}{
+		column: aqua.Expr(s.quote(column)+" + ?", delta),
+	})
+}
+
+func (s *stmt) Decrement(ctx context.Context, column string, delta int) error {
+	return s.Update(ctx, map[string]interface{}{
+		column: aqua.Expr(s.quote(column)+" - ?", delta),
+	})
+}
+
+func (s *stmt) Delete(ctx context.Context, param interface{}) error {
+	err := s.resetInto()
+	if err != nil {
+		return err
+	}
+
+	v := addressable(indirect(reflect.ValueOf(param)))
+	if v.Kind() == reflect.Ptr {
+		return fmt.Errorf(`cannot delete by nil %T`, param)
+	}
+	err = s.hook(ctx, aqua.HookBeforeDelete, v)
+	if err != nil {
+		return err
+	}
+
+	err = s.delete(ctx, param, v)
+	if err != nil {
+		return err
+	}
+
+	return s.hook(ctx, aqua.HookAfterDelete, v)
+}
+
+func (s *stmt) delete(ctx context.Context, param interface{}, v reflect.Value) error {
+	// nil deletes by conditions of the builder, struct deletes by primary key
+	wheres := append([]clause{}, s.wheres...)
+	m := s.model
+	if param != nil {
+		var err error
+		m, err = aqua.ModelOf(v.Type())
+		if err != nil {
+			return err
+		}
+
+		pkWheres, err := s.primaryKeyWheres(m, v)
+		if err != nil {
+			return fmt.Errorf(`cannot delete by %T: %w`, param, err)
+		}
+		wheres = append(wheres, pkWheres...)
+	}
+	if r := aqua.TableModel(s.table); r != nil && r.SoftDelete() != nil && !s.hard {
+		if m == nil || m.SoftDelete() == nil {
+			return fmt.Errorf(`%s is registered with soft delete marker of %s, delete by it or HardDelete()`, s.table, r.Type)
+		}
+	}
+
+	err := s.guard("DELETE", wheres)
+	if err != nil {
+		return err
+	}
+
+	if m != nil && m.SoftDelete() != nil && !s.hard {
+		return s.softDelete(ctx, param, v, m, wheres)
+	}
+
+	where, args := whereSQL(wheres)
+	query := fmt.Sprintf("DELETE FROM %s%s", s.quote(s.table), where)
+	if s.hasReturn {
+		_, err = s.execReturning(ctx, query, args, param)
+		return err
+	}
+	_, err = s.db.exec(ctx, query, args)
+
+	return err
+}
+
+// primaryKeyWheres identifies the row of v by every primary key
+func (s *stmt) primaryKeyWheres(m *aqua.Model, v reflect.Value) ([]clause, error) {
+	pks := m.PrimaryKeys()
+	if len(pks) == 0 {
+		return nil, fmt.Errorf(`no primary key`)
+	}
+
+	wheres := make([]clause, 0, len(pks))
+	for _, f := range pks {
+		if f.IsZero(v) {
+			return nil, fmt.Errorf(`primary key %s is zero`, f.Name)
+		}
+		wheres = append(wheres, clause{
+			sql:  fmt.Sprintf("%s = ?", s.quote(f.Column)),
+			args: []interface{}{f.Value(v).Interface()},
+		})
+	}
+	return wheres, nil
+}
+
+func (s *stmt) WithScanMode(mode aqua.ScanMode) aqua.StmtRunner {
+	s.scanMode = mode
+	return s
+}
+
+func (s *stmt) Only(columns ...string) aqua.StmtWrite {
+	s.only = columns
+	return s
+}
+
+func (s *stmt) Omit(columns ...string) aqua.StmtWrite {
+	s.omit = columns
+	return s
+}
+
+// writes tells whether field f takes part in Create/Update, and whether it
+// is named by Only
+func (s *stmt) writes(f *aqua.Field) (write, named bool) {
+	if contains(s.omit, f.Column) {
+		return false, false
+	}
+	if len(s.only) > 0 {
+		named = contains(s.only, f.Column)
+		return named, named
+	}
+	return true, false
+}
+
+// checkColumns refuses columns of Only/Omit not mapped in the model
+func (s *stmt) checkColumns(m *aqua.Model) error {
+	for _, c := range append(append([]string{}, s.only...), s.omit...) {
+		if m.FieldByColumn(c) == nil {
+			return fmt.Errorf(`%s has no column "%s"`, m.Type, c)
+		}
+	}
+	return nil
+}
+
+func (s *stmt) Since(before interface{}) aqua.StmtRunner {
+	s.before = before
+	return s
+}
+
+func (s *stmt) AllowFullTable() aqua.StmtRunner {
+	s.allowFull = true
+	return s
+}
+
+// guard refuses statement affecting every row by mistake
+func (s *stmt) guard(verb string, wheres []clause) error {
+	if len(wheres) == 0 && !s.allowFull {
+		return fmt.Errorf("%w: %s %s", aqua.ErrFullTable, verb, s.table)
+	}
+	return nil
+}
+
+// resetInto empties s.into, returned rows are appended to it
+func (s *stmt) resetInto() error {
+	if s.into == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(s.into)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf(`dest of Into should be a pointer to slice, not %T`, s.into)
+	}
+	v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), 0, 0))
+	return nil
+}
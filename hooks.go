@@ -0,0 +1,102 @@
+package aqua
+
+import (
+	"context"
+)
+
+// Models implement hooks below to be called by providers. An error from a
+// before hook aborts the statement, and an error from an after hook is
+// returned after the statement. Use RunnerFromContext in hooks to run
+// queries in the same transaction.
+type (
+	BeforeCreateHook interface {
+		BeforeCreate(ctx context.Context) error
+	}
+	AfterCreateHook interface {
+		AfterCreate(ctx context.Context) error
+	}
+	BeforeUpdateHook interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+	AfterUpdateHook interface {
+		AfterUpdate(ctx context.Context) error
+	}
+	BeforeDeleteHook interface {
+		BeforeDelete(ctx context.Context) error
+	}
+	AfterDeleteHook interface {
+		AfterDelete(ctx context.Context) error
+	}
+	// AfterFind is called after a row is scanned into the struct.
+	AfterFindHook interface {
+		AfterFind(ctx context.Context) error
+	}
+)
+
+type Hook int
+
+const (
+	HookBeforeCreate Hook = iota
+	HookAfterCreate
+	HookBeforeUpdate
+	HookAfterUpdate
+	HookBeforeDelete
+	HookAfterDelete
+	HookAfterFind
+)
+
+// CallHook calls hook of v if implemented. Providers call it with ctx
+// carrying the runner by WithRunner.
+func CallHook(ctx context.Context, hook Hook, v interface{}) error {
+	switch hook {
+	case HookBeforeCreate:
+		if h, ok := v.(BeforeCreateHook); ok {
+			return h.BeforeCreate(ctx)
+		}
+	case HookAfterCreate:
+		if h, ok := v.(AfterCreateHook); ok {
+			return h.AfterCreate(ctx)
+		}
+	case HookBeforeUpdate:
+		if h, ok := v.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(ctx)
+		}
+	case HookAfterUpdate:
+		if h, ok := v.(AfterUpdateHook); ok {
+			return h.AfterUpdate(ctx)
+		}
+	case HookBeforeDelete:
+		if h, ok := v.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(ctx)
+		}
+	case HookAfterDelete:
+		if h, ok := v.(AfterDeleteHook); ok {
+			return h.AfterDelete(ctx)
+		}
+	case HookAfterFind:
+		if h, ok := v.(AfterFindHook); ok {
+			return h.AfterFind(ctx)
+		}
+	}
+	return nil
+}
+
+type runnerKey struct{}
+
+// WithRunner returns ctx carrying runner, the DB or Tx running the statement.
+func WithRunner(ctx context.Context, runner QueryRunner) context.Context {
+	return context.WithValue(ctx, runnerKey{}, runner)
+}
+
+// RunnerFromContext returns the runner of the statement calling the hook,
+// which is the Tx inside a transaction, or nil.
+func RunnerFromContext(ctx context.Context) QueryRunner {
+	r, _ := ctx.Value(runnerKey{}).(QueryRunner)
+	return r
+}
+
+// TxFromContext returns the Tx when the hook is called inside a transaction.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := RunnerFromContext(ctx).(Tx)
+	return tx, ok
+}
@@ -0,0 +1,42 @@
+package aqua
+
+import (
+	"context"
+	"iter"
+)
+
+// Iter streams rows of stmt one by one. Rows are closed when the loop ends
+// by break, return or error. An error, including Rows.Err(), is yielded as
+// the last value.
+//
+//	for v, err := range aqua.Iter[personRow](ctx, db.Table("person")) {
+//		if err != nil { ... }
+//	}
+func Iter[T any](ctx context.Context, stmt StmtRunner) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := stmt.All(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var v T
+			err := rows.ScanRow(&v)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
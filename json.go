@@ -0,0 +1,67 @@
+package aqua
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// jsonValue marshals field with json option on bind. nil is NULL.
+type jsonValue struct {
+	v interface{}
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	rv := reflect.ValueOf(j.v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	case reflect.Invalid:
+		return nil, nil
+	}
+
+	b, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (j jsonValue) String() string {
+	b, _ := json.Marshal(j.v)
+	return string(b)
+}
+
+// jsonScanner unmarshals column into field with json option
+type jsonScanner struct {
+	dest  reflect.Value // pointer to the field type
+	valid bool
+}
+
+func (s *jsonScanner) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		s.valid = false
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf(`cannot unmarshal %T as JSON`, src)
+	}
+
+	s.valid = true
+	return json.Unmarshal(b, s.dest.Interface())
+}
+
+func (s *jsonScanner) value() reflect.Value {
+	if !s.valid {
+		return reflect.Value{}
+	}
+	return s.dest.Elem()
+}
@@ -0,0 +1,380 @@
+package aqua
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// NamingStrategy decides column name of struct field without `aqua` tag.
+type NamingStrategy interface {
+	ColumnName(field string) string
+}
+
+// SnakeCase converts "PersonID" into "person_id".
+type SnakeCase struct{}
+
+func (SnakeCase) ColumnName(field string) string {
+	runes := []rune(field)
+	buf := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					buf.WriteRune('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+var naming NamingStrategy = SnakeCase{}
+
+// SetNamingStrategy replaces the naming strategy and drops cached models.
+func SetNamingStrategy(n NamingStrategy) {
+	modelMutex.Lock()
+	defer modelMutex.Unlock()
+
+	naming = n
+	models = map[reflect.Type]*Model{}
+}
+
+// Field is a struct field mapped to a column.
+//
+//	aqua:"column,pk,auto,readonly,omitempty,json,autocreate,autoupdate,softdelete,version"
+//	aqua:"-"
+type Field struct {
+	Name          string
+	Column        string
+	Index         []int
+	Type          reflect.Type
+	PrimaryKey    bool
+	AutoIncrement bool // given by the database on insert when zero
+	ReadOnly      bool // never written by Create/Update
+	OmitEmpty     bool // not written when zero
+	JSON          bool // marshaled into JSON column
+	AutoCreate    bool // set to current time on insert when zero
+	AutoUpdate    bool // set to current time on update, and on insert when zero
+	SoftDelete    bool // marks the row deleted with current time instead of DELETE
+	Version       bool // integer incremented on update, which fails when it is changed by others
+}
+
+// Value returns the field of v, a struct value of the model.
+func (f *Field) Value(v reflect.Value) reflect.Value {
+	return v.FieldByIndex(f.Index)
+}
+
+// Arg returns the field of v to bind, which marshals itself for JSON field.
+func (f *Field) Arg(v reflect.Value) interface{} {
+	if f.JSON {
+		return jsonValue{f.Value(v).Interface()}
+	}
+	return f.Value(v).Interface()
+}
+
+// SetTime sets t to the field of v, which is time.Time, *time.Time,
+// sql.NullTime, Null[time.Time] or integer of unix seconds.
+func (f *Field) SetTime(v reflect.Value, t time.Time) error {
+	err := setTime(f.Value(v), t)
+	if err != nil {
+		return fmt.Errorf(`cannot set time to %s.%s: %w`, v.Type(), f.Name, err)
+	}
+	return nil
+}
+
+// TimeArg returns t as a value of the field to bind.
+func (f *Field) TimeArg(t time.Time) (interface{}, error) {
+	fv := reflect.New(f.Type).Elem()
+	err := setTime(fv, t)
+	if err != nil {
+		return nil, fmt.Errorf(`cannot set time to %s: %w`, f.Name, err)
+	}
+	return fv.Interface(), nil
+}
+
+func setTime(fv reflect.Value, t time.Time) error {
+	switch p := fv.Addr().Interface().(type) {
+	case *time.Time:
+		*p = t
+	case **time.Time:
+		*p = &t
+	case *sql.NullTime:
+		*p = sql.NullTime{Time: t, Valid: true}
+	case *Null[time.Time]:
+		*p = NewNull(t)
+	default:
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			fv.SetInt(t.Unix())
+		default:
+			return fmt.Errorf(`unsupported type %s`, fv.Type())
+		}
+	}
+	return nil
+}
+
+// nullableTime tells whether t can hold either a time or "not set"
+func nullableTime(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf((*time.Time)(nil)), reflect.TypeOf(sql.NullTime{}), reflect.TypeOf(Null[time.Time]{}):
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func integer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// Nullable tells whether zero value of the field is NULL, like nil pointer,
+// invalid sql.NullString or Null[T].
+func (f *Field) Nullable() bool {
+	switch f.Type.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return true
+	}
+	if valuer, ok := reflect.Zero(f.Type).Interface().(driver.Valuer); ok {
+		v, err := valuer.Value()
+		return err == nil && v == nil
+	}
+	return false
+}
+
+func (f *Field) IsZero(v reflect.Value) bool {
+	return f.Value(v).IsZero()
+}
+
+// Model is the mapping of a struct type to columns.
+type Model struct {
+	Type    reflect.Type
+	Fields  []*Field
+	columns map[string]*Field
+}
+
+// FieldByColumn returns the field mapped to column, or nil.
+func (m *Model) FieldByColumn(column string) *Field {
+	return m.columns[column]
+}
+
+// SoftDelete returns the soft delete marker, or nil.
+func (m *Model) SoftDelete() *Field {
+	for _, f := range m.Fields {
+		if f.SoftDelete {
+			return f
+		}
+	}
+	return nil
+}
+
+// Version returns the field for optimistic locking, or nil.
+func (m *Model) Version() *Field {
+	for _, f := range m.Fields {
+		if f.Version {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *Model) PrimaryKeys() []*Field {
+	fields := []*Field{}
+	for _, f := range m.Fields {
+		if f.PrimaryKey {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+var (
+	modelMutex sync.RWMutex
+	models     map[reflect.Type]*Model = map[reflect.Type]*Model{}
+)
+
+var (
+	tableMutex sync.RWMutex
+	// table name => struct type of the model
+	tables = map[string]reflect.Type{}
+)
+
+// RegisterModel binds the table to the model of v, so that statements of
+// the table without Model() are of the model: soft deleted rows are
+// excluded, and Delete marks rows or is refused for struct without the
+// marker. nil v unbinds the table.
+func RegisterModel(table string, v interface{}) error {
+	tableMutex.Lock()
+	defer tableMutex.Unlock()
+
+	if v == nil {
+		delete(tables, table)
+		return nil
+	}
+	m, err := ModelOf(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	tables[table] = m.Type
+	return nil
+}
+
+// TableModel returns the model registered for the table, or nil.
+func TableModel(table string) *Model {
+	tableMutex.RLock()
+	t, ok := tables[table]
+	tableMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	m, _ := ModelOf(t)
+	return m
+}
+
+// ModelOf returns the cached mapping of struct type t. Pointer types are
+// dereferenced.
+func ModelOf(t reflect.Type) (*Model, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(`model should be a struct, not %v`, t)
+	}
+
+	modelMutex.RLock()
+	m, ok := models[t]
+	modelMutex.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	modelMutex.Lock()
+	defer modelMutex.Unlock()
+	if m, ok := models[t]; ok {
+		return m, nil
+	}
+
+	m = &Model{
+		Type:    t,
+		columns: map[string]*Field{},
+	}
+	err := m.parse(t, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.PrimaryKeys()) == 0 {
+		// convention: ID is an autoincrement primary key
+		if f := m.FieldByColumn(naming.ColumnName("ID")); f != nil && f.Name == "ID" {
+			f.PrimaryKey = true
+			f.AutoIncrement = true
+		}
+	}
+
+	models[t] = m
+	return m, nil
+}
+
+func (m *Model) parse(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("aqua")
+		if tag == "-" {
+			continue
+		}
+
+		idx := append(append([]int{}, index...), i)
+
+		// flatten embedded struct
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+			err := m.parse(sf.Type, idx)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+
+		f := &Field{
+			Name:  sf.Name,
+			Index: idx,
+			Type:  sf.Type,
+		}
+
+		opts := strings.Split(tag, ",")
+		f.Column = strings.TrimSpace(opts[0])
+		for _, opt := range opts[1:] {
+			switch strings.TrimSpace(opt) {
+			case "pk":
+				f.PrimaryKey = true
+			case "auto":
+				f.AutoIncrement = true
+			case "readonly":
+				f.ReadOnly = true
+			case "omitempty":
+				f.OmitEmpty = true
+			case "json":
+				f.JSON = true
+			case "autocreate":
+				f.AutoCreate = true
+			case "autoupdate":
+				f.AutoUpdate = true
+			case "softdelete":
+				f.SoftDelete = true
+			case "version":
+				f.Version = true
+			case "":
+			default:
+				return fmt.Errorf(`unknown option "%s" in aqua tag of %s.%s`, opt, t.Name(), sf.Name)
+			}
+		}
+		if f.Column == "" {
+			f.Column = naming.ColumnName(sf.Name)
+		}
+		if f.SoftDelete && !nullableTime(f.Type) {
+			// zero time.Time written on insert would look deleted
+			return fmt.Errorf(`softdelete field %s.%s should be *time.Time, sql.NullTime, Null[time.Time] or integer, not %s`, t.Name(), sf.Name, sf.Type)
+		}
+		if f.Version && !integer(f.Type) {
+			// incremented in place on update
+			return fmt.Errorf(`version field %s.%s should be integer, not %s`, t.Name(), sf.Name, sf.Type)
+		}
+
+		if prev, ok := m.columns[f.Column]; ok {
+			// shallower field wins as in Go, then the first declared one
+			if len(f.Index) < len(prev.Index) {
+				for j := range m.Fields {
+					if m.Fields[j] == prev {
+						m.Fields[j] = f
+					}
+				}
+				m.columns[f.Column] = f
+			}
+			continue
+		}
+		m.Fields = append(m.Fields, f)
+		m.columns[f.Column] = f
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package aqua
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ID":         "id",
+		"PersonID":   "person_id",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Data":       "data",
+		"Address2":   "address2",
+	}
+
+	for field, expected := range cases {
+		actual := SnakeCase{}.ColumnName(field)
+		if actual != expected {
+			t.Errorf(`%s: expected %s, but actual %s`, field, expected, actual)
+		}
+	}
+}
+
+type modelBase struct {
+	CreatedAt time.Time
+}
+
+type taggedModel struct {
+	Key   int    `aqua:"id,pk,auto"`
+	Body  string `aqua:"data"`
+	Owner int    `aqua:"person_id,omitempty"`
+	Count int    `aqua:",readonly"`
+	Tags  []int  `aqua:",json"`
+	Note  string `aqua:"-"`
+	memo  string
+	modelBase
+}
+
+func TestModelOf(t *testing.T) {
+	m, err := ModelOf(reflect.TypeOf(&taggedModel{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+
+	columns := []string{}
+	for _, f := range m.Fields {
+		columns = append(columns, f.Column)
+	}
+	expected := []string{"id", "data", "person_id", "count", "tags", "created_at"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf(`expected columns are %v, but actual %v`, expected, columns)
+	}
+
+	pks := m.PrimaryKeys()
+	if len(pks) != 1 || pks[0].Name != "Key" || !pks[0].AutoIncrement {
+		t.Errorf(`unexpected primary keys: %v`, pks)
+	}
+	if f := m.FieldByColumn("person_id"); f == nil || !f.OmitEmpty {
+		t.Errorf(`person_id should be omitempty: %v`, f)
+	}
+	if f := m.FieldByColumn("count"); f == nil || !f.ReadOnly {
+		t.Errorf(`count should be readonly: %v`, f)
+	}
+	if f := m.FieldByColumn("tags"); f == nil || !f.JSON {
+		t.Errorf(`tags should be json: %v`, f)
+	}
+
+	v := reflect.ValueOf(taggedModel{modelBase: modelBase{CreatedAt: time.Unix(1, 0)}})
+	if m.FieldByColumn("created_at").IsZero(v) {
+		t.Errorf(`embedded field should be accessible`)
+	}
+
+	// cached
+	m2, _ := ModelOf(reflect.TypeOf(taggedModel{}))
+	if m != m2 {
+		t.Errorf(`model should be cached`)
+	}
+}
+
+type conflictBase struct {
+	ID   int
+	Name string
+}
+
+func TestModelOfConflict(t *testing.T) {
+	type outer struct {
+		conflictBase
+		Name string
+	}
+	m, err := ModelOf(reflect.TypeOf(outer{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	if len(m.Fields) != 2 {
+		t.Errorf(`unexpected fields: %v`, m.Fields)
+	}
+	v := reflect.ValueOf(outer{conflictBase: conflictBase{Name: "base"}, Name: "outer"})
+	if f := m.FieldByColumn("name"); f == nil || f.Value(v).String() != "outer" {
+		t.Errorf(`shallower field should win: %v`, f)
+	}
+}
+
+func TestModelOfSoftDelete(t *testing.T) {
+	type valid struct {
+		ID        int
+		DeletedAt Null[time.Time] `aqua:",softdelete"`
+	}
+	m, err := ModelOf(reflect.TypeOf(valid{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	if f := m.SoftDelete(); f == nil || f.Column != "deleted_at" {
+		t.Errorf(`unexpected soft delete marker: %v`, f)
+	}
+
+	type invalid struct {
+		ID        int
+		DeletedAt time.Time `aqua:",softdelete"`
+	}
+	_, err = ModelOf(reflect.TypeOf(invalid{}))
+	if err == nil {
+		t.Errorf(`expected error for softdelete of time.Time`)
+	}
+}
+
+func TestModelOfVersion(t *testing.T) {
+	type valid struct {
+		ID   int
+		Lock uint32 `aqua:",version"`
+	}
+	m, err := ModelOf(reflect.TypeOf(valid{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	if f := m.Version(); f == nil || f.Column != "lock" {
+		t.Errorf(`unexpected version field: %v`, f)
+	}
+
+	type invalid struct {
+		ID   int
+		Lock string `aqua:",version"`
+	}
+	_, err = ModelOf(reflect.TypeOf(invalid{}))
+	if err == nil {
+		t.Errorf(`expected error for version of string`)
+	}
+}
+
+func TestModelOfConvention(t *testing.T) {
+	type conventional struct {
+		ID       int
+		PersonID int
+	}
+
+	m, err := ModelOf(reflect.TypeOf(conventional{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	pks := m.PrimaryKeys()
+	if len(pks) != 1 || pks[0].Column != "id" || !pks[0].AutoIncrement {
+		t.Errorf(`ID should be an autoincrement primary key: %v`, pks)
+	}
+
+	type invalid struct {
+		ID int `aqua:"id,primary"`
+	}
+	if _, err := ModelOf(reflect.TypeOf(invalid{})); err == nil {
+		t.Errorf(`unknown tag option should be an error`)
+	}
+}
+
+type upperNaming struct{}
+
+func (upperNaming) ColumnName(field string) string {
+	return "X_" + field
+}
+
+func TestSetNamingStrategy(t *testing.T) {
+	type named struct {
+		Data string
+	}
+
+	SetNamingStrategy(upperNaming{})
+	defer SetNamingStrategy(SnakeCase{})
+
+	m, err := ModelOf(reflect.TypeOf(named{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	if m.Fields[0].Column != "X_Data" {
+		t.Errorf(`expected column is X_Data, but actual %s`, m.Fields[0].Column)
+	}
+}
+
+func TestFieldSetTime(t *testing.T) {
+	type stamped struct {
+		CreatedAt time.Time       `aqua:",autocreate"`
+		UpdatedAt Null[time.Time] `aqua:",autoupdate"`
+		Unix      int64           `aqua:",autoupdate"`
+		Name      string          `aqua:",autoupdate"`
+	}
+	m, err := ModelOf(reflect.TypeOf(stamped{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+	if !m.Fields[0].AutoCreate || !m.Fields[1].AutoUpdate {
+		t.Errorf(`unexpected options: %v %v`, m.Fields[0], m.Fields[1])
+	}
+
+	now := time.Unix(100, 0)
+	v := reflect.ValueOf(&stamped{}).Elem()
+	for _, f := range m.Fields[:3] {
+		err := f.SetTime(v, now)
+		if err != nil {
+			t.Errorf(`failed to set time to %s: %s`, f.Name, err)
+		}
+	}
+	expected := stamped{CreatedAt: now, UpdatedAt: NewNull(now), Unix: 100}
+	if v.Interface() != expected {
+		t.Errorf(`expected %v, but actual %v`, expected, v.Interface())
+	}
+
+	if m.Fields[3].SetTime(v, now) == nil {
+		t.Errorf(`expected error for string field`)
+	}
+}
+
+func TestFieldNullable(t *testing.T) {
+	type row struct {
+		Name    string
+		Ptr     *string
+		NullStr sql.NullString
+		Null    Null[int]
+		Tags    []string
+	}
+
+	m, err := ModelOf(reflect.TypeOf(row{}))
+	if err != nil {
+		t.Fatalf(`failed to parse model: %s`, err)
+	}
+
+	expected := map[string]bool{"name": false, "ptr": true, "null_str": true, "null": true, "tags": false}
+	for _, f := range m.Fields {
+		if f.Nullable() != expected[f.Column] {
+			t.Errorf(`%s: expected nullable %v`, f.Column, expected[f.Column])
+		}
+	}
+}
+
+func TestRegisterModel(t *testing.T) {
+	err := RegisterModel("tagged", &taggedModel{})
+	if err != nil {
+		t.Fatalf(`failed to register model: %s`, err)
+	}
+	if m := TableModel("tagged"); m == nil || m.Type != reflect.TypeOf(taggedModel{}) {
+		t.Errorf(`unexpected model of table: %v`, m)
+	}
+
+	RegisterModel("tagged", nil)
+	if m := TableModel("tagged"); m != nil {
+		t.Errorf(`expected no model after unbound, but actual %v`, m)
+	}
+
+	err = RegisterModel("tagged", 1)
+	if err == nil {
+		t.Errorf(`expected error for model of int`)
+	}
+}
@@ -0,0 +1,45 @@
+package aqua
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Null is a nullable column value of any type T, which is scanned as
+// sql.Null[T] does and written as NULL when not Valid.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull returns a valid Null holding v.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+func (n *Null[T]) Scan(src interface{}) error {
+	s := sql.Null[T]{}
+	err := s.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	n.V, n.Valid = s.V, s.Valid
+	return nil
+}
+
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// Ptr returns pointer to the value, or nil when not Valid.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
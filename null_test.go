@@ -0,0 +1,34 @@
+package aqua
+
+import (
+	"testing"
+)
+
+func TestNull(t *testing.T) {
+	n := Null[int]{}
+	err := n.Scan(int64(5))
+	if err != nil {
+		t.Fatalf(`failed to scan: %s`, err)
+	}
+	if n != NewNull(5) {
+		t.Errorf(`expected valid 5, but actual %v`, n)
+	}
+
+	v, err := n.Value()
+	if err != nil || v != int64(5) {
+		t.Errorf(`expected value int64(5), but actual %#v (%v)`, v, err)
+	}
+
+	err = n.Scan(nil)
+	if err != nil {
+		t.Fatalf(`failed to scan NULL: %s`, err)
+	}
+	if n.Valid || n.Ptr() != nil {
+		t.Errorf(`expected invalid, but actual %v`, n)
+	}
+
+	v, err = n.Value()
+	if err != nil || v != nil {
+		t.Errorf(`expected nil value, but actual %#v (%v)`, v, err)
+	}
+}
@@ -0,0 +1,24 @@
+package aqua
+
+// PageInfo describes a page fetched by StmtAggregate.Page.
+type PageInfo struct {
+	Page    int // 1-origin
+	PerPage int
+	Total   int // rows matching the conditions
+	Pages   int
+	HasNext bool
+	HasPrev bool
+}
+
+// NewPageInfo computes page metadata from total rows.
+func NewPageInfo(page, perPage, total int) PageInfo {
+	pages := (total + perPage - 1) / perPage
+	return PageInfo{
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		Pages:   pages,
+		HasNext: page < pages,
+		HasPrev: page > 1,
+	}
+}
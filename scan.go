@@ -0,0 +1,369 @@
+package aqua
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Aliased returns columns of model, a struct value, qualified by table and
+// aliased as "table.column" for Select. ScanStruct fills such columns into
+// the nested struct field named table.
+//
+//	Select(append(Aliased("test", testRow{}), Aliased("person", personRow{})...)...)
+func Aliased(table string, model interface{}) []string {
+	m, err := ModelOf(reflect.TypeOf(model))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	columns := make([]string, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		c := table + "." + f.Column
+		columns = append(columns, c+" AS "+c)
+	}
+	return columns
+}
+
+// binding is the destination of a column, field of nested struct parent
+// when parent is not nil
+type binding struct {
+	parent *Field
+	field  *Field
+}
+
+// nested returns field for column "table.column" in the struct field named
+// table, or in the flattened fields for an embedded struct
+func (m *Model) nested(column string) binding {
+	dot := strings.Index(column, ".")
+	if dot < 0 {
+		return binding{}
+	}
+	table, name := column[:dot], column[dot+1:]
+
+	if parent := m.FieldByColumn(table); parent != nil {
+		t := parent.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			if pm, err := ModelOf(t); err == nil {
+				if f := pm.FieldByColumn(name); f != nil {
+					return binding{parent: parent, field: f}
+				}
+			}
+		}
+		return binding{}
+	}
+
+	return binding{field: m.FieldByColumn(name)}
+}
+
+// ScanMode decides how ScanStructWith treats columns without field and
+// fields without column.
+type ScanMode int
+
+const (
+	ScanLoose   ScanMode = iota // ignore silently
+	ScanLenient                 // log a warning
+	ScanStrict                  // return ErrScanMismatch
+)
+
+// ScanOptions configures ScanStructWith.
+type ScanOptions struct {
+	Mode    ScanMode
+	Dialect string // name of the dialect to look up types by RegisterType
+	Time    TimePolicy
+}
+
+// ScanStruct scans the current row of rows into dest, a pointer to struct.
+// Columns without field are discarded and NULL leaves the field zero.
+// Columns aliased as "table.column" fill the nested struct field named table,
+// which is left nil if it is a pointer and all of its columns are NULL.
+func ScanStruct(rows *sql.Rows, dest interface{}) error {
+	return ScanStructWith(rows, dest, ScanOptions{})
+}
+
+// ScanStructWith is ScanStruct with options.
+func ScanStructWith(rows *sql.Rows, dest interface{}, opts ScanOptions) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf(`dest should be a pointer to struct, not %T`, dest)
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		// **T
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf(`dest should be a pointer to struct, not %T`, dest)
+	}
+
+	m, err := ModelOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	targets := make([]interface{}, len(columns))
+	bindings := make([]binding, len(columns))
+	used := map[binding]bool{}
+	unmatched := []string{}
+	for i, c := range columns {
+		b := binding{field: m.FieldByColumn(c)}
+		if b.field == nil {
+			b = m.nested(c)
+		}
+		if b.field == nil || used[b] {
+			// e.g. second "id" of joined table
+			targets[i] = new(interface{})
+			unmatched = append(unmatched, c)
+			continue
+		}
+		used[b] = true
+		bindings[i] = b
+		if b.field.JSON {
+			targets[i] = &jsonScanner{dest: reflect.New(b.field.Type)}
+			continue
+		}
+		if s := decoder(opts.Dialect, b.field.Type); s != nil {
+			targets[i] = s
+			continue
+		}
+		if s := newTimeScanner(b.field.Type); s != nil {
+			targets[i] = s
+			continue
+		}
+		// scan via pointer so that NULL is acceptable
+		targets[i] = reflect.New(reflect.PtrTo(b.field.Type)).Interface()
+	}
+
+	if opts.Mode != ScanLoose {
+		err = mismatch(m, used, unmatched)
+		if err != nil {
+			if opts.Mode == ScanStrict {
+				return err
+			}
+			log.Printf("[aqua] warning: %s", err)
+		}
+	}
+
+	err = rows.Scan(targets...)
+	if err != nil {
+		return err
+	}
+
+	// nested pointer is allocated only when any of its columns is not NULL
+	nested := map[*Field]reflect.Value{}
+	for i, b := range bindings {
+		if b.parent == nil || b.parent.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		if _, ok := nested[b.parent]; !ok {
+			nested[b.parent] = reflect.Value{}
+		}
+		if scanned(targets[i]).IsValid() && !nested[b.parent].IsValid() {
+			nested[b.parent] = reflect.New(b.parent.Type.Elem())
+		}
+	}
+	for parent, p := range nested {
+		if p.IsValid() {
+			parent.Value(v).Set(p)
+		} else {
+			parent.Value(v).Set(reflect.Zero(parent.Type))
+		}
+	}
+
+	for i, b := range bindings {
+		if b.field == nil {
+			continue
+		}
+
+		sv := v
+		if b.parent != nil {
+			sv = b.parent.Value(v)
+			if sv.Kind() == reflect.Ptr {
+				if sv.IsNil() {
+					continue
+				}
+				sv = sv.Elem()
+			}
+		}
+
+		p := scanned(targets[i])
+		fv := b.field.Value(sv)
+		if !p.IsValid() {
+			fv.Set(reflect.Zero(b.field.Type))
+		} else {
+			fv.Set(p)
+			opts.Time.normalizeValue(fv)
+		}
+	}
+	Track(v.Addr().Interface())
+
+	return nil
+}
+
+// scanned returns the value scanned into target of a field, or invalid
+// Value for NULL
+func scanned(target interface{}) reflect.Value {
+	if s, ok := target.(interface{ value() reflect.Value }); ok {
+		return s.value()
+	}
+
+	p := reflect.ValueOf(target).Elem()
+	if p.IsNil() {
+		return reflect.Value{}
+	}
+	return p.Elem()
+}
+
+// mismatch describes unmatched columns and fields without column, or
+// returns nil when every column and field matched
+func mismatch(m *Model, used map[binding]bool, unmatched []string) error {
+	parents := map[*Field]bool{}
+	for b := range used {
+		if b.parent != nil {
+			parents[b.parent] = true
+		}
+	}
+
+	missing := []string{}
+	for _, f := range m.Fields {
+		if used[binding{field: f}] {
+			continue
+		}
+		if !parents[f] {
+			missing = append(missing, f.Name)
+			continue
+		}
+
+		pm, err := ModelOf(f.Type)
+		if err != nil {
+			return err
+		}
+		for _, pf := range pm.Fields {
+			if !used[binding{parent: f, field: pf}] {
+				missing = append(missing, f.Name+"."+pf.Name)
+			}
+		}
+	}
+
+	if len(unmatched) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	msgs := []string{}
+	if len(unmatched) > 0 {
+		msgs = append(msgs, fmt.Sprintf("columns without field: %s", strings.Join(unmatched, ", ")))
+	}
+	if len(missing) > 0 {
+		msgs = append(msgs, fmt.Sprintf("fields without column: %s", strings.Join(missing, ", ")))
+	}
+	return fmt.Errorf("%w: %s (%s)", ErrScanMismatch, m.Type, strings.Join(msgs, "; "))
+}
+
+// ScanMap scans the current row of rows into dest, a pointer to
+// map[string]interface{} keyed by column. []byte of textual columns becomes
+// string, and of numeric columns becomes int64 or float64.
+func ScanMap(rows *sql.Rows, dest *map[string]interface{}) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	targets := make([]interface{}, len(columns))
+	for i := range targets {
+		targets[i] = new(interface{})
+	}
+	err = rows.Scan(targets...)
+	if err != nil {
+		return err
+	}
+
+	if *dest == nil {
+		*dest = make(map[string]interface{}, len(columns))
+	}
+	for i, c := range columns {
+		(*dest)[c] = naturalValue(*(targets[i].(*interface{})), types[i])
+	}
+
+	return nil
+}
+
+func naturalValue(v interface{}, ct *sql.ColumnType) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	switch scanKind(ct.ScanType(), name) {
+	case reflect.Int64:
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+	case reflect.Uint64:
+		if u, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return u
+		}
+	case reflect.Float64:
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	}
+
+	if strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA" {
+		// driver may reuse the buffer
+		return append([]byte{}, b...)
+	}
+	return string(b)
+}
+
+// scanKind tells the number kind of the column by the scan type, which may be
+// sql.NullInt64 and so on for nullable columns, or by the type name
+func scanKind(t reflect.Type, name string) reflect.Kind {
+	switch t {
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}):
+		return reflect.Int64
+	case reflect.TypeOf(sql.NullByte{}):
+		return reflect.Uint64
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return reflect.Float64
+	}
+	if t != nil {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflect.Int64
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.Uint64
+		case reflect.Float32, reflect.Float64:
+			return reflect.Float64
+		}
+	}
+
+	switch strings.TrimPrefix(name, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT":
+		if strings.HasPrefix(name, "UNSIGNED ") {
+			return reflect.Uint64
+		}
+		return reflect.Int64
+	case "FLOAT", "DOUBLE", "REAL":
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
@@ -0,0 +1,30 @@
+package aqua
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestScanKind(t *testing.T) {
+	cases := []struct {
+		typ      reflect.Type
+		name     string
+		expected reflect.Kind
+	}{
+		{reflect.TypeOf(int32(0)), "INT", reflect.Int64},
+		{reflect.TypeOf(uint64(0)), "UNSIGNED BIGINT", reflect.Uint64},
+		{reflect.TypeOf(sql.NullInt64{}), "BIGINT", reflect.Int64},
+		{reflect.TypeOf(sql.NullFloat64{}), "DOUBLE", reflect.Float64},
+		{reflect.TypeOf(sql.RawBytes{}), "DECIMAL", reflect.Invalid},
+		{reflect.TypeOf(sql.RawBytes{}), "VARCHAR", reflect.Invalid},
+		{nil, "UNSIGNED INT", reflect.Uint64},
+		{nil, "REAL", reflect.Float64},
+	}
+	for _, c := range cases {
+		actual := scanKind(c.typ, c.name)
+		if actual != c.expected {
+			t.Errorf(`%v %s: expected %v, but actual %v`, c.typ, c.name, c.expected, actual)
+		}
+	}
+}
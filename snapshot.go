@@ -0,0 +1,128 @@
+package aqua
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Snapshot embedded in a model keeps column values as loaded, so that Update
+// writes only changed columns and skips the round trip when nothing changed.
+// It is taken on scan, Create and Update, or by Track.
+type Snapshot struct {
+	image *image
+}
+
+type image map[string]interface{}
+
+func (s Snapshot) snapshot() *image {
+	return s.image
+}
+
+func (s *Snapshot) take(i *image) {
+	s.image = i
+}
+
+type tracked interface {
+	snapshot() *image
+}
+
+// Track takes the snapshot of v, a pointer to struct embedding Snapshot.
+// Other values are ignored.
+func Track(v interface{}) {
+	t, ok := v.(interface{ take(*image) })
+	if !ok {
+		return
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	m, err := ModelOf(rv.Type())
+	if err != nil {
+		return
+	}
+
+	i := m.image(rv)
+	t.take(&i)
+}
+
+// Changes returns fields of v, a struct value of the model, which differ
+// from before, or from the snapshot of v when before is nil. ok is false
+// when neither is available.
+func (m *Model) Changes(v reflect.Value, before interface{}) (fields []*Field, ok bool, err error) {
+	var prev image
+	if before != nil {
+		bv := reflect.Indirect(reflect.ValueOf(before))
+		if bv.Type() != m.Type {
+			return nil, false, fmt.Errorf(`before image should be %s, not %T`, m.Type, before)
+		}
+		prev = m.image(bv)
+	} else if t, ok := v.Interface().(tracked); ok && t.snapshot() != nil {
+		prev = *t.snapshot()
+	} else {
+		return nil, false, nil
+	}
+
+	current := m.image(v)
+	fields = []*Field{}
+	for _, f := range m.Fields {
+		if !reflect.DeepEqual(prev[f.Column], current[f.Column]) {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields, true, nil
+}
+
+// image copies column values of v to compare
+func (m *Model) image(v reflect.Value) image {
+	i := image{}
+	for _, f := range m.Fields {
+		fv := f.Value(v)
+		if f.JSON {
+			// compare marshaled, so that shared maps and slices are detected
+			i[f.Column] = jsonValue{fv.Interface()}.String()
+			continue
+		}
+		i[f.Column] = deepCopy(fv).Interface()
+	}
+	return i
+}
+
+// deepCopy copies pointers, maps and slices in v, so that in-place edits
+// of the field are detected
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	}
+	return v
+}
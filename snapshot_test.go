@@ -0,0 +1,69 @@
+package aqua
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	type row struct {
+		Snapshot
+		ID   int
+		Name string
+		Tags []string `aqua:",json"`
+		Memo *string
+	}
+
+	m, err := ModelOf(reflect.TypeOf(row{}))
+	if err != nil {
+		t.Fatalf(`failed to get model: %s`, err)
+	}
+	if len(m.Fields) != 4 {
+		t.Errorf(`Snapshot should not be a field: %v`, m.Fields)
+	}
+
+	columns := func(fields []*Field) []string {
+		result := []string{}
+		for _, f := range fields {
+			result = append(result, f.Column)
+		}
+		return result
+	}
+
+	memo := "old"
+	r := row{ID: 1, Name: "a", Tags: []string{"x"}, Memo: &memo}
+	_, ok, err := m.Changes(reflect.ValueOf(r), nil)
+	if ok || err != nil {
+		t.Errorf(`expected untracked, but actual %v (%v)`, ok, err)
+	}
+
+	Track(&r)
+	r.Tags[0] = "y"
+	fields, ok, err := m.Changes(reflect.ValueOf(r), nil)
+	if !ok || err != nil {
+		t.Fatalf(`expected tracked, but actual %v (%v)`, ok, err)
+	}
+	if cols := columns(fields); !reflect.DeepEqual(cols, []string{"tags"}) {
+		t.Errorf(`unexpected changes: %v`, cols)
+	}
+
+	// in-place edit through pointer
+	Track(&r)
+	*r.Memo = "new"
+	fields, _, _ = m.Changes(reflect.ValueOf(r), nil)
+	if cols := columns(fields); !reflect.DeepEqual(cols, []string{"memo"}) {
+		t.Errorf(`unexpected changes of pointer: %v`, cols)
+	}
+
+	before := r
+	r.Name = ""
+	fields, _, _ = m.Changes(reflect.ValueOf(r), before)
+	if cols := columns(fields); !reflect.DeepEqual(cols, []string{"name"}) {
+		t.Errorf(`unexpected changes since before: %v`, cols)
+	}
+
+	_, _, err = m.Changes(reflect.ValueOf(r), struct{}{})
+	if err == nil {
+		t.Errorf(`expected error for before image of other type`)
+	}
+}
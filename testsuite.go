@@ -2,8 +2,13 @@ package aqua
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +45,30 @@ func (t *TestSuite) Run() {
 	t.testDelete()
 	t.testTx()
 	t.testRows()
+	t.testReservedWord()
+	t.testReturning()
+	t.testInsertFrom()
+	t.testExpr()
+	t.testFullTableGuard()
+	t.testTagMapping()
+	t.testCompositeKey()
+	t.testGeneric()
+	t.testIter()
+	t.testEach()
+	t.testPaginate()
+	t.testPage()
+	t.testScanMap()
+	t.testScanMode()
+	t.testNull()
+	t.testRegisterType()
+	t.testJSON()
+	t.testTimePolicy()
+	t.testHooks()
+	t.testAutoTimestamp()
+	t.testSoftDelete()
+	t.testOptimisticLock()
+	t.testDirtyTracking()
+	t.testOnlyOmit()
 	t.testMisc()
 
 	os.Remove(dbfile)
@@ -79,7 +108,7 @@ created_at TIMESTAMP
 type testRow struct {
 	ID       int
 	Data     string
-	PersonID int
+	PersonID Null[int]
 }
 type personRow struct {
 	ID        int
@@ -224,22 +253,21 @@ func (t *TestSuite) testJoin() {
 		&testRow{
 			ID:       100,
 			Data:     "acidlemon-test",
-			PersonID: 1,
+			PersonID: NewNull(1),
 		},
 		&testRow{
 			ID:       101,
 			Data:     "macopy-test",
-			PersonID: 2,
+			PersonID: NewNull(2),
 		},
 		&testRow{
-			ID:       102,
-			Data:     "null",
-			PersonID: 0,
+			ID:   102,
+			Data: "null",
 		},
 		&testRow{
 			ID:       103,
 			Data:     "acidlemon-test2",
-			PersonID: 1,
+			PersonID: NewNull(1),
 		},
 	}...)
 	if err != nil {
@@ -290,6 +318,66 @@ func (t *TestSuite) testJoin() {
 		}
 	}
 
+	// nested struct by aliased columns
+	{
+		joined := []struct {
+			Test   testRow
+			Person *personRow
+		}{}
+
+		rows, err := t.db.Table("test").
+			Select(append(Aliased("test", testRow{}), Aliased("person", personRow{})...)...).
+			LeftJoin("person", "test.person_id = person.id").
+			Where("test.id >= 100").
+			OrderBy("test.id").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to left join test & person: %s`, err)
+		}
+		defer rows.Close()
+
+		err = rows.ScanAll(&joined)
+		if err != nil {
+			t.Fatalf(`failed to scan joined rows: %s`, err)
+		}
+
+		if len(joined) != 4 {
+			t.Fatalf(`expected row count is 4, but actual %d`, len(joined))
+		}
+		if joined[0].Test.Data != "acidlemon-test" || joined[0].Person == nil || joined[0].Person.Name != "acidlemon" {
+			t.Errorf(`unexpected joined row: %+v %+v`, joined[0].Test, joined[0].Person)
+		}
+		if joined[1].Person == nil || joined[1].Person.ID != 2 || joined[1].Person.Name != "macopy" {
+			t.Errorf(`unexpected joined person: %+v`, joined[1].Person)
+		}
+		if joined[2].Test.ID != 102 || joined[2].Person != nil {
+			t.Errorf(`expected nil person for unmatched row, but actual %+v`, joined[2].Person)
+		}
+	}
+
+	// embedded struct takes columns of its own table
+	{
+		var joined struct {
+			testRow
+			Person personRow
+		}
+
+		row, err := t.db.Table("test").
+			Select(append(Aliased("test", testRow{}), Aliased("person", personRow{})...)...).
+			Join("person", "test.person_id = person.id").
+			Where("test.id = ?", 101).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to join test & person: %s`, err)
+		}
+
+		err = row.ScanRow(&joined)
+		if err != nil {
+			t.Fatalf(`failed to scan joined row: %s`, err)
+		}
+		if joined.ID != 101 || joined.Data != "macopy-test" || joined.Person.Name != "macopy" {
+			t.Errorf(`unexpected joined row: %+v`, joined)
+		}
+	}
+
 	// RightJoin
 	/* Currently sqlite3 does not suppor RIGHT JOIN
 	{
@@ -571,7 +659,7 @@ func (t *TestSuite) fetchTestRow(ctx context.Context, runner QueryRunner, id int
 	var r testRow
 	row, err := runner.Table("test").WhereEq("id", id).Single(ctx)
 	if err != nil {
-		t.Fatalf(`failed to get test row (id = %s): %s`, id, err)
+		t.Fatalf(`failed to get test row (id = %d): %s`, id, err)
 	}
 	err = row.ScanRow(&r)
 	if err != nil {
@@ -601,20 +689,19 @@ func (t *TestSuite) testUpdate() {
 		}
 	}
 
-	// update using new struct
+	// update using new struct without key must fail, even with conditions
 	{
-		newData := "new-struct acidlemon-test"
 		r := testRow{
-			Data: newData,
+			Data: "new-struct acidlemon-test",
 		}
 		err := t.db.Table("test").WhereEq("id", 100).Update(ctx, &r)
-		if err != nil {
-			t.Fatalf(`failed to update row using struct: %s`, err)
+		if err == nil {
+			t.Errorf(`update by struct without key should fail`)
 		}
 
 		r = t.fetchTestRow(ctx, t.db, 100)
-		if r.Data != newData {
-			t.Errorf(`Data did not update correctly, actual: %s`, r.Data)
+		if r.Data != "updated acidlemon-test" {
+			t.Errorf(`Data should not be updated, actual: %s`, r.Data)
 		}
 	}
 
@@ -764,15 +851,14 @@ func (t *TestSuite) testRows() {
 	expected := []testRow{{
 		ID:       103,
 		Data:     "acidlemon-test2",
-		PersonID: 1,
+		PersonID: NewNull(1),
 	}, {
-		ID:       102,
-		Data:     "null",
-		PersonID: 0,
+		ID:   102,
+		Data: "null",
 	}, {
 		ID:       101,
 		Data:     "transaction-commit macopy-test",
-		PersonID: 2,
+		PersonID: NewNull(2),
 	}}
 	for rows.Next() {
 
@@ -794,7 +880,7 @@ func (t *TestSuite) testRows() {
 			}
 
 			if expected[i].PersonID != r.PersonID {
-				t.Errorf(`expected PersonID is %d, but actual PersonID is %d`,
+				t.Errorf(`expected PersonID is %v, but actual PersonID is %v`,
 					expected[i].PersonID, r.PersonID)
 			}
 		}
@@ -812,6 +898,2116 @@ func (t *TestSuite) testRows() {
 
 }
 
+type reservedRow struct {
+	ID    int
+	Order int
+	User  string
+}
+
+func (t *TestSuite) testReservedWord() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE "group" (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+"order" INTEGER,
+"user" VARCHAR(80)
+)`)
+	if err != nil {
+		t.Fatalf(`failed to crate table: %s`, err)
+	}
+
+	err = t.db.Table("group").Create(ctx, []interface{}{
+		&reservedRow{Order: 1, User: "acidlemon"},
+		&reservedRow{Order: 2, User: "macopy"},
+		&reservedRow{Order: 3, User: "acidlemon"},
+	}...)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+
+	// where & order by
+	{
+		rows, err := t.db.Table("group").WhereEq("user", "acidlemon").OrderBy("order DESC").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to get group data: %s`, err)
+		}
+		defer rows.Close()
+
+		result := []reservedRow{}
+		err = rows.ScanAll(&result)
+		if err != nil {
+			t.Fatalf(`failed to scan fetched data: %s`, err)
+		}
+
+		expected := []reservedRow{
+			{ID: 3, Order: 3, User: "acidlemon"},
+			{ID: 1, Order: 1, User: "acidlemon"},
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf(`expected rows are %v, but actual %v`, expected, result)
+		}
+	}
+
+	// select & update & delete
+	{
+		err := t.db.Table("group").WhereEq("order", 2).Update(ctx, map[string]interface{}{
+			"user": "updated",
+		})
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+
+		var order int
+		var user string
+		row, err := t.db.Table("group").Select("order", "user").WhereEq("id", 2).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to get group data: %s`, err)
+		}
+		err = row.Scan(&order, &user)
+		if err != nil {
+			t.Fatalf(`failed to scan single row: %s`, err)
+		}
+		if order != 2 || user != "updated" {
+			t.Errorf(`unexpected row: order=%d, user=%s`, order, user)
+		}
+
+		err = t.db.Table("group").Delete(ctx, &reservedRow{ID: 2})
+		if err != nil {
+			t.Fatalf(`failed to delete row: %s`, err)
+		}
+
+		cnt, err := t.db.Table("group").WhereIn("order", 1, 2, 3).Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count group table: %s`, err)
+		}
+		if cnt != 2 {
+			t.Errorf(`expect count is 2, but actual %d`, cnt)
+		}
+	}
+}
+
+type returningInput struct {
+	ID   int
+	Data string
+}
+type returningRow struct {
+	ID     int
+	Data   string
+	Status string
+}
+
+func (t *TestSuite) testReturning() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE returning_test (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+data VARCHAR(80),
+status VARCHAR(20) DEFAULT 'new'
+)`)
+	if err != nil {
+		t.Fatalf(`failed to crate table: %s`, err)
+	}
+
+	if !t.db.Dialect().SupportsReturning() {
+		// e.g. SQLite before 3.35.0
+		err = t.db.Table("returning_test").Returning().Create(ctx, &returningInput{Data: "old"})
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf(`expected ErrUnsupported, but actual %v`, err)
+		}
+		return
+	}
+
+	// returning into passed struct
+	{
+		r := returningRow{Data: "first"}
+		err := t.db.Table("returning_test").Returning().Create(ctx, &returningInput{Data: r.Data})
+		if err != nil {
+			t.Fatalf(`failed to create row: %s`, err)
+		}
+
+		err = t.db.Table("returning_test").Returning("status").Create(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to create row: %s`, err)
+		}
+		// status is set by the struct, id is filled by returning
+		if r.ID != 2 {
+			t.Errorf(`expected id is 2, but actual %d`, r.ID)
+		}
+	}
+
+	// returning into destination slice
+	{
+		result := []returningRow{}
+		err := t.db.Table("returning_test").Returning("id", "status").Into(&result).
+			Create(ctx, &returningInput{Data: "third"}, &returningInput{Data: "fourth"})
+		if err != nil {
+			t.Fatalf(`failed to create rows: %s`, err)
+		}
+
+		expected := []returningRow{{ID: 3, Status: "new"}, {ID: 4, Status: "new"}}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf(`expected returned rows are %v, but actual %v`, expected, result)
+		}
+
+		err = t.db.Table("returning_test").WhereEq("status", "new").Returning("id", "status").Into(&result).
+			Update(ctx, map[string]interface{}{"status": "done"})
+		if err != nil {
+			t.Fatalf(`failed to update rows: %s`, err)
+		}
+
+		expected = []returningRow{{ID: 1, Status: "done"}, {ID: 3, Status: "done"}, {ID: 4, Status: "done"}}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf(`expected returned rows are %v, but actual %v`, expected, result)
+		}
+	}
+
+	// returning deleted row
+	{
+		r := returningRow{ID: 3}
+		err := t.db.Table("returning_test").Returning().Delete(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to delete row: %s`, err)
+		}
+
+		expected := returningRow{ID: 3, Data: "third", Status: "done"}
+		if r != expected {
+			t.Errorf(`expected returned row is %v, but actual %v`, expected, r)
+		}
+	}
+}
+
+func (t *TestSuite) testInsertFrom() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE test_history (
+id INTEGER PRIMARY KEY,
+data VARCHAR(80),
+archived INTEGER DEFAULT 1
+)`)
+	if err != nil {
+		t.Fatalf(`failed to crate table: %s`, err)
+	}
+
+	// same columns
+	{
+		cnt, err := t.db.Table("test_history").InsertFrom(ctx, []string{"id", "data"},
+			t.db.Table("test").Where("id >= 100").OrderBy("id"))
+		if err != nil {
+			t.Fatalf(`failed to insert from test: %s`, err)
+		}
+		if cnt != 3 {
+			t.Errorf(`expected inserted row count is 3, but actual %d`, cnt)
+		}
+
+		rows, err := t.db.Table("test_history").OrderBy("id").FetchColumn(ctx, "data")
+		if err != nil {
+			t.Fatalf(`failed to fetch test_history: %s`, err)
+		}
+		defer rows.Close()
+
+		result := []string{}
+		err = rows.ScanAll(&result)
+		if err != nil {
+			t.Fatalf(`failed to scan columns: %s`, err)
+		}
+		expected := []string{"transaction-commit macopy-test", "null", "acidlemon-test2"}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf(`expected slice=%v, but actual slice=%v`, expected, result)
+		}
+	}
+
+	// select expressions with join
+	{
+		cnt, err := t.db.Table("test_history").InsertFrom(ctx, []string{"id", "data"},
+			t.db.Table("test").Join("person", "test.person_id = person.id").
+				Select("test.id + 1000", "person.name").WhereEq("person.name", "acidlemon"))
+		if err != nil {
+			t.Fatalf(`failed to insert from test & person: %s`, err)
+		}
+		if cnt != 1 {
+			t.Errorf(`expected inserted row count is 1, but actual %d`, cnt)
+		}
+
+		cnt2, err := t.db.Table("test_history").WhereEq("data", "acidlemon").Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count test_history: %s`, err)
+		}
+		if cnt2 != 1 {
+			t.Errorf(`expected count is 1, but actual %d`, cnt2)
+		}
+	}
+}
+
+type counterRow struct {
+	ID        int
+	Views     int
+	UpdatedAt *time.Time
+}
+
+func (t *TestSuite) testExpr() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE counter (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+views INTEGER,
+updated_at TIMESTAMP NULL
+)`)
+	if err != nil {
+		t.Fatalf(`failed to crate table: %s`, err)
+	}
+
+	fetch := func() counterRow {
+		var r counterRow
+		row, err := t.db.Table("counter").WhereEq("id", 1).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to get counter row: %s`, err)
+		}
+		err = row.ScanRow(&r)
+		if err != nil {
+			t.Fatalf(`failed to scan row: %s`, err)
+		}
+		return r
+	}
+
+	// expression in create values
+	err = t.db.Table("counter").Create(ctx, map[string]interface{}{
+		"views": Expr("? * 5", 2),
+	})
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	if r := fetch(); r.Views != 10 {
+		t.Errorf(`expected views is 10, but actual %d`, r.Views)
+	}
+
+	// increment & decrement
+	err = t.db.Table("counter").WhereEq("id", 1).Increment(ctx, "views", 3)
+	if err != nil {
+		t.Fatalf(`failed to increment: %s`, err)
+	}
+	err = t.db.Table("counter").WhereEq("id", 1).Decrement(ctx, "views", 1)
+	if err != nil {
+		t.Fatalf(`failed to decrement: %s`, err)
+	}
+	if r := fetch(); r.Views != 12 {
+		t.Errorf(`expected views is 12, but actual %d`, r.Views)
+	}
+
+	// expression in update map
+	err = t.db.Table("counter").WhereEq("id", 1).Update(ctx, map[string]interface{}{
+		"views":      Expr("views * ?", 2),
+		"updated_at": Expr("CURRENT_TIMESTAMP"),
+	})
+	if err != nil {
+		t.Fatalf(`failed to update row: %s`, err)
+	}
+	r := fetch()
+	if r.Views != 24 {
+		t.Errorf(`expected views is 24, but actual %d`, r.Views)
+	}
+	if r.UpdatedAt == nil || r.UpdatedAt.IsZero() {
+		t.Errorf(`expected updated_at is set, but actual %v`, r.UpdatedAt)
+	}
+}
+
+func (t *TestSuite) testFullTableGuard() {
+	ctx := context.Background()
+
+	count := func() int {
+		cnt, err := t.db.Table("test_history").Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count test_history: %s`, err)
+		}
+		return cnt
+	}
+	before := count()
+
+	// delete by conditions
+	err := t.db.Table("test_history").WhereEq("data", "acidlemon").Delete(ctx, nil)
+	if err != nil {
+		t.Fatalf(`failed to delete by condition: %s`, err)
+	}
+	if cnt := count(); cnt != before-1 {
+		t.Errorf(`expected count is %d, but actual %d`, before-1, cnt)
+	}
+
+	// struct without primary key value
+	err = t.db.Table("test_history").Delete(ctx, &testRow{Data: "null"})
+	if err == nil {
+		t.Errorf(`delete by blank primary key should fail`)
+	}
+
+	// unconditioned statements are refused
+	err = t.db.Table("test_history").Delete(ctx, nil)
+	if !errors.Is(err, ErrFullTable) {
+		t.Errorf(`expected ErrFullTable, but actual %v`, err)
+	}
+	err = t.db.Table("test_history").Update(ctx, map[string]interface{}{"archived": 0})
+	if !errors.Is(err, ErrFullTable) {
+		t.Errorf(`expected ErrFullTable, but actual %v`, err)
+	}
+	if cnt := count(); cnt != before-1 {
+		t.Errorf(`expected count is %d, but actual %d`, before-1, cnt)
+	}
+
+	// unless explicitly allowed
+	err = t.db.Table("test_history").AllowFullTable().Update(ctx, map[string]interface{}{"archived": 0})
+	if err != nil {
+		t.Fatalf(`failed to update whole table: %s`, err)
+	}
+	err = t.db.Table("test_history").AllowFullTable().Delete(ctx, nil)
+	if err != nil {
+		t.Fatalf(`failed to delete whole table: %s`, err)
+	}
+	if cnt := count(); cnt != 0 {
+		t.Errorf(`expected count is 0, but actual %d`, cnt)
+	}
+}
+
+type taggedTestRow struct {
+	Key   int    `aqua:"id,pk,auto"`
+	Body  string `aqua:"data"`
+	Owner int    `aqua:"person_id,omitempty"`
+	Note  string `aqua:"-"`
+}
+
+func (t *TestSuite) testTagMapping() {
+	ctx := context.Background()
+
+	// create
+	r := taggedTestRow{
+		Body: "tagged",
+		Note: "not a column",
+	}
+	err := t.db.Table("test").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	if r.Key == 0 {
+		t.Errorf(`expected autofilled key, but actual 0`)
+	}
+
+	// scan
+	var r2 taggedTestRow
+	row, err := t.db.Table("test").WhereEq("id", r.Key).Single(ctx)
+	if err != nil {
+		t.Fatalf(`failed to get test row: %s`, err)
+	}
+	err = row.ScanRow(&r2)
+	if err != nil {
+		t.Fatalf(`failed to scan row: %s`, err)
+	}
+	expected := taggedTestRow{Key: r.Key, Body: "tagged"}
+	if r2 != expected {
+		t.Errorf(`expected row is %v, but actual %v`, expected, r2)
+	}
+
+	// update & delete by primary key
+	r2.Body = "tagged-updated"
+	r2.Owner = 2
+	err = t.db.Table("test").Update(ctx, &r2)
+	if err != nil {
+		t.Fatalf(`failed to update row: %s`, err)
+	}
+	tr := t.fetchTestRow(ctx, t.db, r.Key)
+	if tr.Data != "tagged-updated" || tr.PersonID != NewNull(2) {
+		t.Errorf(`row did not update correctly, actual: %v`, tr)
+	}
+
+	err = t.db.Table("test").Delete(ctx, &r2)
+	if err != nil {
+		t.Fatalf(`failed to delete row: %s`, err)
+	}
+	tr = t.fetchTestRow(ctx, t.db, r.Key)
+	if tr.ID != 0 {
+		t.Errorf(`row exists, expected result is no row`)
+	}
+}
+
+type memberRow struct {
+	TenantID int `aqua:",pk"`
+	ID       int `aqua:",pk"`
+	Name     string
+}
+
+func (t *TestSuite) testCompositeKey() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE member (
+tenant_id INTEGER,
+id INTEGER,
+name VARCHAR(80),
+PRIMARY KEY (tenant_id, id)
+)`)
+	if err != nil {
+		t.Fatalf(`failed to crate table: %s`, err)
+	}
+
+	err = t.db.Table("member").Create(ctx, []interface{}{
+		&memberRow{TenantID: 1, ID: 1, Name: "acidlemon"},
+		&memberRow{TenantID: 2, ID: 1, Name: "macopy"},
+		&memberRow{TenantID: 2, ID: 2, Name: "unused"},
+	}...)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+
+	fetchNames := func() []string {
+		rows, err := t.db.Table("member").OrderBy("tenant_id", "id").FetchColumn(ctx, "name")
+		if err != nil {
+			t.Fatalf(`failed to fetch member: %s`, err)
+		}
+		defer rows.Close()
+
+		names := []string{}
+		err = rows.ScanAll(&names)
+		if err != nil {
+			t.Fatalf(`failed to scan columns: %s`, err)
+		}
+		return names
+	}
+
+	// update by composite key
+	err = t.db.Table("member").Update(ctx, &memberRow{TenantID: 2, ID: 1, Name: "updated"})
+	if err != nil {
+		t.Fatalf(`failed to update row: %s`, err)
+	}
+	expected := []string{"acidlemon", "updated", "unused"}
+	if names := fetchNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf(`expected names are %v, but actual %v`, expected, names)
+	}
+
+	// delete by composite key
+	err = t.db.Table("member").Delete(ctx, &memberRow{TenantID: 2, ID: 2})
+	if err != nil {
+		t.Fatalf(`failed to delete row: %s`, err)
+	}
+	expected = []string{"acidlemon", "updated"}
+	if names := fetchNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf(`expected names are %v, but actual %v`, expected, names)
+	}
+
+	// nil pointer must fail
+	err = t.db.Table("member").Update(ctx, (*memberRow)(nil))
+	if err == nil {
+		t.Errorf(`update by nil pointer should fail`)
+	}
+	err = t.db.Table("member").Delete(ctx, (*memberRow)(nil))
+	if err == nil {
+		t.Errorf(`delete by nil pointer should fail`)
+	}
+	err = t.db.Table("member").Create(ctx, (*memberRow)(nil))
+	if err == nil {
+		t.Errorf(`create by nil pointer should fail`)
+	}
+	err = t.db.Table("member").Update(ctx, nil)
+	if err == nil {
+		t.Errorf(`update by nil should fail`)
+	}
+	err = t.db.Table("member").Create(ctx, nil)
+	if err == nil {
+		t.Errorf(`create by nil should fail`)
+	}
+
+	// partial key must fail
+	err = t.db.Table("member").Update(ctx, &memberRow{ID: 1, Name: "broken"})
+	if err == nil {
+		t.Errorf(`update by partial key should fail`)
+	}
+	err = t.db.Table("member").Delete(ctx, &memberRow{ID: 1})
+	if err == nil {
+		t.Errorf(`delete by partial key should fail`)
+	}
+	if names := fetchNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf(`expected names are %v, but actual %v`, expected, names)
+	}
+}
+
+func (t *TestSuite) testGeneric() {
+	ctx := context.Background()
+
+	// Insert
+	rows := []personRow{
+		{Name: "generic1", CreatedAt: t.mustTime("2017-06-11 10:00:00")},
+		{Name: "generic2", CreatedAt: t.mustTime("2017-06-11 11:00:00")},
+	}
+	err := From[personRow](t.db, "person").Insert(ctx, rows...)
+	if err != nil {
+		t.Fatalf(`failed to insert: %s`, err)
+	}
+	if rows[0].ID == 0 || rows[1].ID == 0 {
+		t.Errorf(`expected autofilled ids, but actual %v`, rows)
+	}
+
+	// All
+	people, err := From[personRow](t.db, "person").WhereLike("name", "generic%").OrderBy("id DESC").All(ctx)
+	if err != nil {
+		t.Fatalf(`failed to get all: %s`, err)
+	}
+	if len(people) != 2 || people[0].Name != "generic2" || people[1].Name != "generic1" {
+		t.Errorf(`unexpected rows: %v`, people)
+	}
+
+	// First
+	p, err := From[*personRow](t.db, "person").WhereLike("name", "generic%").OrderBy("id").First(ctx)
+	if err != nil {
+		t.Fatalf(`failed to get first: %s`, err)
+	}
+	if p.ID != rows[0].ID {
+		t.Errorf(`expected id is %d, but actual %d`, rows[0].ID, p.ID)
+	}
+	_, err = From[personRow](t.db, "person").WhereEq("name", "nobody").First(ctx)
+	if err != sql.ErrNoRows {
+		t.Errorf(`expected sql.ErrNoRows, but actual %v`, err)
+	}
+
+	// Get
+	p2, err := From[personRow](t.db, "person").Get(ctx, rows[1].ID)
+	if err != nil {
+		t.Fatalf(`failed to get by primary key: %s`, err)
+	}
+	if p2.Name != "generic2" {
+		t.Errorf(`expected name is generic2, but actual %s`, p2.Name)
+	}
+	m, err := From[memberRow](t.db, "member").Get(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf(`failed to get by composite key: %s`, err)
+	}
+	if m.Name != "updated" {
+		t.Errorf(`expected name is updated, but actual %s`, m.Name)
+	}
+	_, err = From[memberRow](t.db, "member").Get(ctx, 2)
+	if err == nil {
+		t.Errorf(`get by partial key should fail`)
+	}
+
+	// reused query
+	q := From[personRow](t.db, "person").WhereLike("name", "generic%")
+	for _, r := range rows {
+		p, err := q.Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get by reused query: %s`, err)
+		}
+		if p.ID != r.ID {
+			t.Errorf(`expected id is %d, but actual %d`, r.ID, p.ID)
+		}
+	}
+	if cnt, err := q.Stmt().Count(ctx); err != nil || cnt != len(rows) {
+		t.Errorf(`query should not be narrowed by Get: %d (%v)`, cnt, err)
+	}
+}
+
+func (t *TestSuite) testIter() {
+	ctx := context.Background()
+
+	// iterate all
+	{
+		ids := []int{}
+		for r, err := range Iter[testRow](ctx, t.db.Table("test").Where("id >= 100").OrderBy("id")) {
+			if err != nil {
+				t.Fatalf(`failed to iterate: %s`, err)
+			}
+			ids = append(ids, r.ID)
+		}
+		expected := []int{101, 102, 103}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf(`expected ids are %v, but actual %v`, expected, ids)
+		}
+	}
+
+	// break releases the connection
+	{
+		t.db.SetMaxOpenConns(1)
+		for r, err := range From[*testRow](t.db, "test").OrderBy("id").Iter(ctx) {
+			if err != nil {
+				t.Fatalf(`failed to iterate: %s`, err)
+			}
+			if r.ID == 0 {
+				t.Errorf(`expected row is scanned, but actual %v`, r)
+			}
+			break
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		_, err := t.db.Table("test").Count(tctx)
+		if err != nil {
+			t.Errorf(`connection seems to be leaked: %s`, err)
+		}
+		t.db.SetMaxOpenConns(0)
+	}
+
+	// error is yielded as last value
+	{
+		count := 0
+		var lastErr error
+		for _, err := range Iter[testRow](ctx, t.db.Table("no_such_table")) {
+			count++
+			lastErr = err
+		}
+		if count != 1 || lastErr == nil {
+			t.Errorf(`expected one error, but actual count=%d, err=%v`, count, lastErr)
+		}
+	}
+}
+
+func (t *TestSuite) testEach() {
+	ctx := context.Background()
+
+	// walk every row even when rows are inserted during the walk
+	{
+		ids := []int{}
+		batches := 0
+		buf := []*testRow{}
+		err := t.db.Table("test").Where("id >= 100").Each(ctx, 2, &buf, func(batch interface{}) error {
+			batches++
+			for _, r := range batch.([]*testRow) {
+				ids = append(ids, r.ID)
+			}
+			if batches == 1 {
+				return t.db.Table("test").Create(ctx, &testRow{ID: 104, Data: "inserted while walking"})
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf(`failed to walk rows: %s`, err)
+		}
+
+		expected := []int{101, 102, 103, 104}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf(`expected ids are %v, but actual %v`, expected, ids)
+		}
+		if batches != 2 {
+			t.Errorf(`expected batch count is 2, but actual %d`, batches)
+		}
+	}
+
+	// error from callback stops the walk
+	{
+		stop := errors.New("stop")
+		batches := 0
+		err := From[memberRow](t.db, "member").Each(ctx, 1, func(batch []memberRow) error {
+			batches++
+			if len(batch) != 1 {
+				t.Errorf(`expected batch size is 1, but actual %d`, len(batch))
+			}
+			return stop
+		})
+		if err != stop {
+			t.Errorf(`expected error from callback, but actual %v`, err)
+		}
+		if batches != 1 {
+			t.Errorf(`expected batch count is 1, but actual %d`, batches)
+		}
+	}
+
+	// composite primary key
+	{
+		names := []string{}
+		err := From[memberRow](t.db, "member").Each(ctx, 1, func(batch []memberRow) error {
+			for _, m := range batch {
+				names = append(names, m.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf(`failed to walk rows: %s`, err)
+		}
+		expected := []string{"acidlemon", "updated"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf(`expected names are %v, but actual %v`, expected, names)
+		}
+	}
+
+	err := t.db.Table("test").Delete(ctx, &testRow{ID: 104})
+	if err != nil {
+		t.Fatalf(`failed to delete row: %s`, err)
+	}
+}
+
+func (t *TestSuite) testPaginate() {
+	ctx := context.Background()
+
+	// mixed ASC/DESC sort keys including NULL, which sorts as smaller than
+	// any value, pages concatenated equal to the whole
+	for _, orders := range [][]string{{"person_id DESC", "data"}, {"person_id", "data DESC"}} {
+		// sqlite sorts NULL first as Paginate does
+		expected, err := From[testRow](t.db, "test").OrderBy(append(orders, "id")...).All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch rows: %s`, err)
+		}
+		if expected[0].PersonID.Valid == expected[len(expected)-1].PersonID.Valid {
+			t.Fatalf(`test rows should have NULL sort key: %v`, expected)
+		}
+
+		actual := []testRow{}
+		pages := 0
+		var after Cursor
+		for {
+			page, next, err := From[testRow](t.db, "test").OrderBy(orders...).Paginate(ctx, after, 2)
+			if err != nil {
+				t.Fatalf(`failed to paginate by %v: %s`, orders, err)
+			}
+			pages++
+			if len(page) > 2 {
+				t.Errorf(`expected page size is up to 2, but actual %d`, len(page))
+			}
+			actual = append(actual, page...)
+			if next == "" {
+				break
+			}
+			after = next
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf(`%v: expected rows are %v, but actual %v`, orders, expected, actual)
+		}
+		if want := (len(expected) + 1) / 2; pages != want {
+			t.Errorf(`expected page count is %d, but actual %d`, want, pages)
+		}
+	}
+
+	// untyped builder with conditions
+	{
+		rows := []testRow{}
+		next, err := t.db.Table("test").Where("id >= 100").OrderBy("id DESC").Paginate(ctx, "", 2, &rows)
+		if err != nil {
+			t.Fatalf(`failed to paginate: %s`, err)
+		}
+		if len(rows) != 2 || rows[0].ID != 103 || rows[1].ID != 102 {
+			t.Errorf(`unexpected first page: %v`, rows)
+		}
+
+		next, err = t.db.Table("test").Where("id >= 100").OrderBy("id DESC").Paginate(ctx, next, 2, &rows)
+		if err != nil {
+			t.Fatalf(`failed to paginate: %s`, err)
+		}
+		if len(rows) != 1 || rows[0].ID != 101 {
+			t.Errorf(`unexpected last page: %v`, rows)
+		}
+		if next != "" {
+			t.Errorf(`expected no more page, but actual cursor %s`, next)
+		}
+	}
+
+	// errors
+	{
+		rows := []testRow{}
+		_, err := t.db.Table("test").Paginate(ctx, "", 2, &rows)
+		if err == nil {
+			t.Errorf(`expected error without OrderBy`)
+		}
+		_, err = t.db.Table("test").OrderBy("id").Paginate(ctx, "broken!", 2, &rows)
+		if err == nil {
+			t.Errorf(`expected error of invalid cursor`)
+		}
+	}
+}
+
+func (t *TestSuite) testPage() {
+	ctx := context.Background()
+
+	// total ignores OrderBy and LimitOffset of the builder
+	{
+		rows := []testRow{}
+		info, err := t.db.Table("test").Where("id >= 100").OrderBy("id DESC").LimitOffset(1, 1).Page(ctx, 1, 2, &rows)
+		if err != nil {
+			t.Fatalf(`failed to fetch page: %s`, err)
+		}
+
+		expected := PageInfo{Page: 1, PerPage: 2, Total: 3, Pages: 2, HasNext: true, HasPrev: false}
+		if info != expected {
+			t.Errorf(`expected page info is %+v, but actual %+v`, expected, info)
+		}
+		if len(rows) != 2 || rows[0].ID != 103 || rows[1].ID != 102 {
+			t.Errorf(`unexpected rows of page 1: %v`, rows)
+		}
+	}
+
+	// last page
+	{
+		rows, info, err := From[testRow](t.db, "test").Where("id >= 100").OrderBy("id DESC").Page(ctx, 2, 2)
+		if err != nil {
+			t.Fatalf(`failed to fetch page: %s`, err)
+		}
+
+		expected := PageInfo{Page: 2, PerPage: 2, Total: 3, Pages: 2, HasNext: false, HasPrev: true}
+		if info != expected {
+			t.Errorf(`expected page info is %+v, but actual %+v`, expected, info)
+		}
+		if len(rows) != 1 || rows[0].ID != 101 {
+			t.Errorf(`unexpected rows of page 2: %v`, rows)
+		}
+	}
+
+	// page beyond the last is empty
+	{
+		rows, info, err := From[testRow](t.db, "test").Where("id >= 100").Page(ctx, 3, 2)
+		if err != nil {
+			t.Fatalf(`failed to fetch page: %s`, err)
+		}
+		if len(rows) != 0 || info.HasNext || !info.HasPrev {
+			t.Errorf(`unexpected page 3: %v %+v`, rows, info)
+		}
+	}
+
+	// no rows
+	{
+		rows, info, err := From[testRow](t.db, "test").Where("id < 0").Page(ctx, 1, 2)
+		if err != nil {
+			t.Fatalf(`failed to fetch page: %s`, err)
+		}
+		expected := PageInfo{Page: 1, PerPage: 2}
+		if len(rows) != 0 || info != expected {
+			t.Errorf(`unexpected empty page: %v %+v`, rows, info)
+		}
+	}
+
+	rows := []testRow{}
+	_, err := t.db.Table("test").Page(ctx, 0, 2, &rows)
+	if err == nil {
+		t.Errorf(`expected error for page 0`)
+	}
+}
+
+func (t *TestSuite) testScanMap() {
+	ctx := context.Background()
+
+	// rows into slice of map
+	{
+		rows, err := t.db.Table("test").Select("id", "data").Where("id >= 100").OrderBy("id").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			t.Fatalf(`failed to get columns: %s`, err)
+		}
+		if !reflect.DeepEqual(columns, []string{"id", "data"}) {
+			t.Errorf(`unexpected columns: %v`, columns)
+		}
+
+		result := []map[string]interface{}{}
+		err = rows.ScanAll(&result)
+		if err != nil {
+			t.Fatalf(`failed to scan into maps: %s`, err)
+		}
+
+		if len(result) != 3 {
+			t.Fatalf(`expected row count is 3, but actual %d`, len(result))
+		}
+		expected := map[string]interface{}{"id": int64(101), "data": "transaction-commit macopy-test"}
+		if !reflect.DeepEqual(result[0], expected) {
+			t.Errorf(`expected %v, but actual %v`, expected, result[0])
+		}
+	}
+
+	// row into map
+	{
+		row, err := t.db.Table("person").Select("name", "created_at").Where("id = ?", 1).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+
+		var result map[string]interface{}
+		err = row.ScanRow(&result)
+		if err != nil {
+			t.Fatalf(`failed to scan into map: %s`, err)
+		}
+
+		if result["name"] != "acidlemon" {
+			t.Errorf(`expected name is acidlemon, but actual %#v`, result["name"])
+		}
+		if _, ok := result["created_at"]; !ok {
+			t.Errorf(`expected created_at in %v`, result)
+		}
+	}
+}
+
+func (t *TestSuite) testScanMode() {
+	ctx := context.Background()
+
+	// strict per statement
+	{
+		rows, err := t.db.Table("test").Select("id", "data", "id AS renamed").WithScanMode(ScanStrict).All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+		defer rows.Close()
+
+		result := []testRow{}
+		err = rows.ScanAll(&result)
+		if !errors.Is(err, ErrScanMismatch) {
+			t.Fatalf(`expected ErrScanMismatch, but actual %v`, err)
+		}
+		for _, name := range []string{"renamed", "PersonID"} {
+			if !strings.Contains(err.Error(), name) {
+				t.Errorf(`expected %s in error: %s`, name, err)
+			}
+		}
+	}
+
+	// strict per DB, satisfied by exact columns
+	{
+		t.db.SetScanMode(ScanStrict)
+		defer t.db.SetScanMode(ScanLoose)
+
+		result, err := From[testRow](t.db, "test").Where("id >= 100").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to scan strictly: %s`, err)
+		}
+		if len(result) != 3 {
+			t.Errorf(`expected row count is 3, but actual %d`, len(result))
+		}
+
+		_, err = From[personRow](t.db, "test").All(ctx)
+		if !errors.Is(err, ErrScanMismatch) {
+			t.Errorf(`expected ErrScanMismatch, but actual %v`, err)
+		}
+
+		// nested structs
+		joined := []struct {
+			Test   testRow
+			Person *personRow
+		}{}
+		rows, err := t.db.Table("test").
+			Select(append(Aliased("test", testRow{}), Aliased("person", personRow{})...)...).
+			LeftJoin("person", "test.person_id = person.id").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to left join test & person: %s`, err)
+		}
+		defer rows.Close()
+		err = rows.ScanAll(&joined)
+		if err != nil {
+			t.Errorf(`failed to scan nested structs strictly: %s`, err)
+		}
+	}
+
+	// lenient mode only warns
+	{
+		row, err := t.db.Table("test").Select("id", "data").Where("id = ?", 101).WithScanMode(ScanLenient).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+
+		r := testRow{}
+		err = row.ScanRow(&r)
+		if err != nil {
+			t.Errorf(`unexpected error in lenient mode: %s`, err)
+		}
+		if r.ID != 101 {
+			t.Errorf(`expected id is 101, but actual %d`, r.ID)
+		}
+	}
+}
+
+type nullableRow struct {
+	ID       int
+	Data     *string
+	PersonID sql.NullInt64
+}
+
+func (t *TestSuite) testNull() {
+	ctx := context.Background()
+
+	// NULL is written for nil pointer and invalid sql.Null*
+	r := nullableRow{}
+	err := t.db.Table("test").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+
+	cnt, err := t.db.Table("test").WhereEq("id", r.ID).WhereEq("data", nil).WhereEq("person_id", nil).Count(ctx)
+	if err != nil {
+		t.Fatalf(`failed to count: %s`, err)
+	}
+	if cnt != 1 {
+		t.Errorf(`expected NULL columns, but not found`)
+	}
+
+	// NULL is scanned as nil and invalid
+	{
+		actual, err := From[nullableRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if actual.Data != nil || actual.PersonID.Valid {
+			t.Errorf(`expected NULL fields, but actual %v`, actual)
+		}
+
+		tr, err := From[testRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if tr.PersonID.Valid {
+			t.Errorf(`expected invalid person_id, but actual %v`, tr.PersonID)
+		}
+	}
+
+	// values are written through pointer and sql.Null*
+	{
+		data := "nullable"
+		r.Data = &data
+		r.PersonID = sql.NullInt64{Int64: 3, Valid: true}
+		err = t.db.Table("test").Update(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+
+		actual, err := From[nullableRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if actual.Data == nil || *actual.Data != "nullable" || actual.PersonID.Int64 != 3 {
+			t.Errorf(`unexpected row: %v`, actual)
+		}
+
+		tr, err := From[testRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if tr.PersonID != NewNull(3) {
+			t.Errorf(`expected person_id 3, but actual %v`, tr.PersonID)
+		}
+	}
+
+	// nil is skipped by Update like other zero values, and written as NULL
+	// when named by Only
+	{
+		r.Data = nil
+		r.PersonID = sql.NullInt64{}
+		err = t.db.Table("test").Update(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+
+		cnt, err := t.db.Table("test").WhereEq("id", r.ID).WhereEq("data", nil).WhereEq("person_id", nil).Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count: %s`, err)
+		}
+		if cnt != 0 {
+			t.Errorf(`expected nil fields are skipped, but written`)
+		}
+
+		err = t.db.Table("test").Only("data", "person_id").Update(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+
+		cnt, err = t.db.Table("test").WhereEq("id", r.ID).WhereEq("data", nil).WhereEq("person_id", nil).Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count: %s`, err)
+		}
+		if cnt != 1 {
+			t.Errorf(`expected NULL columns after update, but not found`)
+		}
+	}
+
+	err = t.db.Table("test").Delete(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to delete row: %s`, err)
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+type pointRow struct {
+	ID   int
+	Data point
+}
+
+type pointPtrRow struct {
+	ID   int
+	Data *point
+}
+
+func (t *TestSuite) testRegisterType() {
+	ctx := context.Background()
+
+	RegisterType(reflect.TypeOf(point{}),
+		func(v interface{}) (driver.Value, error) {
+			p := v.(point)
+			return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+		},
+		func(src interface{}) (interface{}, error) {
+			var s string
+			switch v := src.(type) {
+			case string:
+				s = v
+			case []byte:
+				s = string(v)
+			default:
+				return nil, fmt.Errorf(`cannot decode %T into point`, src)
+			}
+			p := point{}
+			_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+			return p, err
+		})
+
+	r := pointRow{Data: point{3, 4}}
+	err := t.db.Table("test").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	defer t.db.Table("test").Delete(ctx, &r)
+
+	// encoded in bind
+	tr := t.fetchTestRow(ctx, t.db, r.ID)
+	if tr.Data != "3,4" {
+		t.Errorf(`expected encoded data is "3,4", but actual %s`, tr.Data)
+	}
+
+	// decoded in scan, also as condition
+	{
+		actual, err := From[pointRow](t.db, "test").WhereEq("data", point{3, 4}).First(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch row: %s`, err)
+		}
+		if actual != r {
+			t.Errorf(`expected row is %v, but actual %v`, r, actual)
+		}
+
+		ptr, err := From[pointPtrRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to fetch row: %s`, err)
+		}
+		if ptr.Data == nil || *ptr.Data != r.Data {
+			t.Errorf(`expected data is %v, but actual %v`, r.Data, ptr.Data)
+		}
+	}
+
+	// pluck
+	{
+		rows, err := t.db.Table("test").WhereEq("id", r.ID).FetchColumn(ctx, "data")
+		if err != nil {
+			t.Fatalf(`failed to fetch column: %s`, err)
+		}
+		defer rows.Close()
+
+		points := []point{}
+		err = rows.ScanAll(&points)
+		if err != nil {
+			t.Fatalf(`failed to scan column: %s`, err)
+		}
+		if len(points) != 1 || points[0] != r.Data {
+			t.Errorf(`unexpected points: %v`, points)
+		}
+	}
+
+	// update through pointer, nil pointer is NULL
+	{
+		err = t.db.Table("test").Update(ctx, &pointPtrRow{ID: r.ID, Data: &point{5, 6}})
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+		tr := t.fetchTestRow(ctx, t.db, r.ID)
+		if tr.Data != "5,6" {
+			t.Errorf(`expected encoded data is "5,6", but actual %s`, tr.Data)
+		}
+
+		err = t.db.Table("test").WhereEq("id", r.ID).Update(ctx, map[string]interface{}{"data": (*point)(nil)})
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+		ptr, err := From[pointPtrRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to fetch row: %s`, err)
+		}
+		if ptr.Data != nil {
+			t.Errorf(`expected nil data, but actual %v`, ptr.Data)
+		}
+	}
+}
+
+type settings struct {
+	Theme  string `json:"theme"`
+	Notify bool   `json:"notify"`
+}
+
+type profileRow struct {
+	ID       int
+	Settings settings               `aqua:"settings,json"`
+	Tags     []string               `aqua:",json"`
+	Extra    map[string]interface{} `aqua:",json"`
+}
+
+func (t *TestSuite) testJSON() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE profile (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+settings TEXT,
+tags TEXT,
+extra TEXT NULL
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	r := profileRow{
+		Settings: settings{Theme: "dark", Notify: true},
+		Tags:     []string{"a", "b"},
+	}
+	err = t.db.Table("profile").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	err = t.db.Table("profile").Create(ctx, &profileRow{Settings: settings{Theme: "light"}})
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+
+	// marshaled on Create, nil is NULL
+	{
+		row, err := t.db.Table("profile").Select("settings", "extra").WhereEq("id", r.ID).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+		var raw string
+		var extra sql.NullString
+		err = row.Scan(&raw, &extra)
+		if err != nil {
+			t.Fatalf(`failed to scan: %s`, err)
+		}
+		if raw != `{"theme":"dark","notify":true}` {
+			t.Errorf(`unexpected JSON: %s`, raw)
+		}
+		if extra.Valid {
+			t.Errorf(`expected NULL for nil map, but actual %s`, extra.String)
+		}
+	}
+
+	// unmarshaled on scan, selected by path
+	{
+		actual, err := From[profileRow](t.db, "profile").WhereJSON("settings", "theme", "dark").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch rows: %s`, err)
+		}
+		if len(actual) != 1 || !reflect.DeepEqual(actual[0], r) {
+			t.Errorf(`expected %v, but actual %v`, r, actual)
+		}
+	}
+
+	// marshaled on Update
+	{
+		r.Settings.Theme = "solarized"
+		r.Extra = map[string]interface{}{"level": 3.0}
+		err = t.db.Table("profile").Update(ctx, &r)
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+
+		actual, err := From[profileRow](t.db, "profile").WhereJSON("settings", "$.theme", "solarized").First(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch row: %s`, err)
+		}
+		if !reflect.DeepEqual(actual, r) {
+			t.Errorf(`expected %v, but actual %v`, r, actual)
+		}
+
+		// same on every dialect
+		for _, level := range []interface{}{3, "3", 3.0} {
+			cnt, err := t.db.Table("profile").WhereJSON("extra", "level", level).Count(ctx)
+			if err != nil {
+				t.Fatalf(`failed to count: %s`, err)
+			}
+			if cnt != 1 {
+				t.Errorf(`expected count by %#v is 1, but actual %d`, level, cnt)
+			}
+		}
+
+		// number is compared numerically
+		_, err = t.db.Exec(ctx, `UPDATE profile SET extra = '{"level":3.0}' WHERE id = ?`, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+		for level, expected := range map[interface{}]int{3: 1, 3.0: 1, int64(4): 0, "3": 0, "3.0": 1} {
+			cnt, err := t.db.Table("profile").WhereJSON("extra", "level", level).Count(ctx)
+			if err != nil {
+				t.Fatalf(`failed to count: %s`, err)
+			}
+			if cnt != expected {
+				t.Errorf(`expected count by %#v is %d, but actual %d`, level, expected, cnt)
+			}
+		}
+		cnt, err := t.db.Table("profile").WhereJSON("settings", "notify", true).Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count: %s`, err)
+		}
+		if cnt != 1 {
+			t.Errorf(`expected count by true is 1, but actual %d`, cnt)
+		}
+	}
+}
+
+func (t *TestSuite) testTimePolicy() {
+	ctx := context.Background()
+
+	jst := time.FixedZone("JST", 9*60*60)
+	t.db.SetTimePolicy(TimePolicy{UTC: true, Location: jst, Precision: time.Millisecond})
+	defer t.db.SetTimePolicy(TimePolicy{})
+
+	created := time.Date(2017, 6, 10, 16, 40, 50, 123456789, time.FixedZone("PDT", -7*60*60))
+	expected := created.Truncate(time.Millisecond).In(jst)
+
+	p := personRow{Name: "time-policy", CreatedAt: created}
+	err := t.db.Table("person").Create(ctx, &p)
+	if err != nil {
+		t.Fatalf(`failed to create person: %s`, err)
+	}
+	defer t.db.Table("person").Delete(ctx, &p)
+
+	// stored in UTC, round trip exactly to the precision
+	{
+		t.db.SetTimePolicy(TimePolicy{})
+		actual, err := From[personRow](t.db, "person").Get(ctx, p.ID)
+		t.db.SetTimePolicy(TimePolicy{UTC: true, Location: jst, Precision: time.Millisecond})
+		if err != nil {
+			t.Fatalf(`failed to get person: %s`, err)
+		}
+		if actual.CreatedAt != expected.UTC() {
+			t.Errorf(`expected UTC timestamp %v, but actual %v`, expected.UTC(), actual.CreatedAt)
+		}
+	}
+
+	// scanned in the location, also matched by the same time in condition
+	{
+		actual, err := From[personRow](t.db, "person").WhereEq("created_at", created).First(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch person: %s`, err)
+		}
+		if !actual.CreatedAt.Equal(expected) || actual.CreatedAt.Location() != jst {
+			t.Errorf(`expected created_at is %v, but actual %v`, expected, actual.CreatedAt)
+		}
+	}
+
+	// raw scan is normalized too
+	{
+		row, err := t.db.Table("person").Select("created_at").WhereEq("id", p.ID).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+		var actual time.Time
+		err = row.Scan(&actual)
+		if err != nil {
+			t.Fatalf(`failed to scan: %s`, err)
+		}
+		if !actual.Equal(expected) || actual.Location() != jst {
+			t.Errorf(`expected created_at is %v, but actual %v`, expected, actual)
+		}
+	}
+
+	// time stored as text
+	{
+		_, err := t.db.Exec(ctx, `CREATE TABLE text_time (
+id INTEGER PRIMARY KEY,
+at TEXT,
+at_ptr TEXT,
+at_null TEXT
+)`)
+		if err != nil {
+			t.Fatalf(`failed to create table: %s`, err)
+		}
+		_, err = t.db.Exec(ctx, `INSERT INTO text_time VALUES (1, '2017-06-10 23:40:50.123', '2017-06-10T23:40:50.123Z', NULL)`)
+		if err != nil {
+			t.Fatalf(`failed to insert: %s`, err)
+		}
+
+		actual, err := From[textTimeRow](t.db, "text_time").Get(ctx, 1)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if !actual.At.Equal(expected) || actual.At.Location() != jst ||
+			actual.AtPtr == nil || !actual.AtPtr.Equal(expected) || actual.AtNull.Valid {
+			t.Errorf(`unexpected times: %v`, actual)
+		}
+
+		row, err := t.db.Table("text_time").Select("at").WhereEq("id", 1).Single(ctx)
+		if err != nil {
+			t.Fatalf(`failed to select: %s`, err)
+		}
+		var raw time.Time
+		err = row.Scan(&raw)
+		if err != nil {
+			t.Fatalf(`failed to scan: %s`, err)
+		}
+		if !raw.Equal(expected) || raw.Location() != jst {
+			t.Errorf(`expected at is %v, but actual %v`, expected, raw)
+		}
+	}
+}
+
+type textTimeRow struct {
+	ID     int
+	At     time.Time
+	AtPtr  *time.Time
+	AtNull Null[time.Time]
+}
+
+type hookedRow struct {
+	ID       int
+	Data     string
+	PersonID Null[int]
+
+	calls  []string
+	inTx   bool
+	loaded bool
+}
+
+func (r *hookedRow) record(ctx context.Context, name string) {
+	r.calls = append(r.calls, name)
+	_, r.inTx = TxFromContext(ctx)
+}
+
+func (r *hookedRow) BeforeCreate(ctx context.Context) error {
+	if r.Data == "" {
+		return errors.New("data is required")
+	}
+	r.record(ctx, "BeforeCreate")
+	return nil
+}
+
+func (r *hookedRow) AfterCreate(ctx context.Context) error {
+	r.record(ctx, "AfterCreate")
+	return nil
+}
+
+func (r *hookedRow) BeforeUpdate(ctx context.Context) error {
+	r.record(ctx, "BeforeUpdate")
+
+	// hooks can run queries on the same runner
+	_, err := RunnerFromContext(ctx).Table("test").WhereEq("id", r.ID).Count(ctx)
+	return err
+}
+
+func (r *hookedRow) AfterUpdate(ctx context.Context) error {
+	r.record(ctx, "AfterUpdate")
+	return nil
+}
+
+func (r *hookedRow) BeforeDelete(ctx context.Context) error {
+	r.record(ctx, "BeforeDelete")
+	return nil
+}
+
+func (r *hookedRow) AfterDelete(ctx context.Context) error {
+	r.record(ctx, "AfterDelete")
+	return nil
+}
+
+func (r *hookedRow) AfterFind(ctx context.Context) error {
+	r.loaded = true
+	return nil
+}
+
+func (t *TestSuite) testHooks() {
+	ctx := context.Background()
+
+	// error from before hook aborts
+	{
+		before, _ := t.db.Table("test").Count(ctx)
+		err := t.db.Table("test").Create(ctx, &hookedRow{})
+		if err == nil || err.Error() != "data is required" {
+			t.Errorf(`expected error from hook, but actual %v`, err)
+		}
+		// struct passed by value calls pointer receiver hooks too
+		err = t.db.Table("test").Create(ctx, hookedRow{})
+		if err == nil || err.Error() != "data is required" {
+			t.Errorf(`expected error from hook of value, but actual %v`, err)
+		}
+		after, _ := t.db.Table("test").Count(ctx)
+		if before != after {
+			t.Errorf(`row should not be created`)
+		}
+	}
+
+	r := hookedRow{Data: "hooked"}
+	err := t.db.Table("test").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	if !reflect.DeepEqual(r.calls, []string{"BeforeCreate", "AfterCreate"}) || r.inTx {
+		t.Errorf(`unexpected hook calls: %v in tx: %v`, r.calls, r.inTx)
+	}
+
+	// AfterFind
+	{
+		found, err := From[hookedRow](t.db, "test").Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf(`failed to get row: %s`, err)
+		}
+		if !found.loaded {
+			t.Errorf(`AfterFind was not called`)
+		}
+
+		rows, err := From[*hookedRow](t.db, "test").WhereEq("id", r.ID).All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch rows: %s`, err)
+		}
+		if len(rows) != 1 || !rows[0].loaded {
+			t.Errorf(`AfterFind was not called for pointer`)
+		}
+	}
+
+	// hooks receive Tx
+	{
+		tx, err := t.db.Begin(ctx, nil)
+		if err != nil {
+			t.Fatalf(`failed to begin: %s`, err)
+		}
+
+		r.calls = nil
+		r.Data = "hooked-in-tx"
+		err = tx.Table("test").Update(ctx, &r)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf(`failed to update row: %s`, err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			t.Fatalf(`failed to commit: %s`, err)
+		}
+
+		if !reflect.DeepEqual(r.calls, []string{"BeforeUpdate", "AfterUpdate"}) || !r.inTx {
+			t.Errorf(`unexpected hook calls: %v in tx: %v`, r.calls, r.inTx)
+		}
+	}
+
+	r.calls = nil
+	err = t.db.Table("test").Delete(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to delete row: %s`, err)
+	}
+	if !reflect.DeepEqual(r.calls, []string{"BeforeDelete", "AfterDelete"}) {
+		t.Errorf(`unexpected hook calls: %v`, r.calls)
+	}
+}
+
+type stampedRow struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time  `aqua:",autocreate"`
+	UpdatedAt *time.Time `aqua:",autoupdate"`
+	Touched   int64      `aqua:",autoupdate"`
+}
+
+func (t *TestSuite) testAutoTimestamp() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE stamped (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+name VARCHAR(80),
+created_at TIMESTAMP,
+updated_at TIMESTAMP NULL,
+touched INTEGER
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	t1 := time.Date(2017, 6, 10, 16, 40, 50, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	now := t1
+	t.db.SetClock(func() time.Time { return now })
+	defer t.db.SetClock(nil)
+
+	fetch := func(id int) stampedRow {
+		r, err := From[stampedRow](t.db, "stamped").Get(ctx, id)
+		if err != nil {
+			t.Fatalf(`failed to fetch row: %s`, err)
+		}
+		return r
+	}
+
+	// given on Create
+	r := stampedRow{Name: "stamped"}
+	err = t.db.Table("stamped").Create(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to create row: %s`, err)
+	}
+	if !r.CreatedAt.Equal(t1) || r.UpdatedAt == nil || !r.UpdatedAt.Equal(t1) || r.Touched != t1.Unix() {
+		t.Errorf(`unexpected timestamps: %v %v %d`, r.CreatedAt, r.UpdatedAt, r.Touched)
+	}
+	actual := fetch(r.ID)
+	if !actual.CreatedAt.Equal(t1) || actual.UpdatedAt == nil || !actual.UpdatedAt.Equal(t1) {
+		t.Errorf(`unexpected stored timestamps: %v %v`, actual.CreatedAt, actual.UpdatedAt)
+	}
+
+	// explicit value is kept, also for struct passed by value
+	{
+		created := t1.Add(-time.Hour)
+		err = t.db.Table("stamped").Create(ctx, stampedRow{ID: 100, Name: "explicit", CreatedAt: created})
+		if err != nil {
+			t.Fatalf(`failed to create row: %s`, err)
+		}
+		actual := fetch(100)
+		if !actual.CreatedAt.Equal(created) || actual.UpdatedAt == nil || !actual.UpdatedAt.Equal(t1) {
+			t.Errorf(`unexpected stored timestamps: %v %v`, actual.CreatedAt, actual.UpdatedAt)
+		}
+	}
+
+	// only autoupdate on Update
+	now = t2
+	r.Name = "stamped-updated"
+	err = t.db.Table("stamped").Update(ctx, &r)
+	if err != nil {
+		t.Fatalf(`failed to update row: %s`, err)
+	}
+	actual = fetch(r.ID)
+	if !actual.CreatedAt.Equal(t1) || !actual.UpdatedAt.Equal(t2) || actual.Touched != t2.Unix() {
+		t.Errorf(`unexpected updated timestamps: %v %v %d`, actual.CreatedAt, actual.UpdatedAt, actual.Touched)
+	}
+}
+
+type articleRow struct {
+	ID        int
+	Title     string
+	DeletedAt *time.Time `aqua:",softdelete"`
+}
+
+type plainArticleRow struct {
+	ID    int
+	Title string
+}
+
+func (t *TestSuite) testSoftDelete() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE article (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+title VARCHAR(80),
+deleted_at TIMESTAMP NULL
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	articles := []*articleRow{{Title: "a1"}, {Title: "a2"}, {Title: "a3"}}
+	for _, a := range articles {
+		err = t.db.Table("article").Create(ctx, a)
+		if err != nil {
+			t.Fatalf(`failed to create article: %s`, err)
+		}
+	}
+
+	count := func(stmt StmtRunner) int {
+		cnt, err := stmt.Count(ctx)
+		if err != nil {
+			t.Fatalf(`failed to count: %s`, err)
+		}
+		return cnt
+	}
+
+	// Delete marks the row
+	a1 := articles[0]
+	err = t.db.Table("article").Delete(ctx, a1)
+	if err != nil {
+		t.Fatalf(`failed to delete article: %s`, err)
+	}
+	if a1.DeletedAt == nil {
+		t.Errorf(`expected deleted_at is set to the struct`)
+	}
+
+	// queries of the model are scoped
+	{
+		all, err := From[articleRow](t.db, "article").OrderBy("id").All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch articles: %s`, err)
+		}
+		if len(all) != 2 || all[0].Title != "a2" {
+			t.Errorf(`unexpected articles: %v`, all)
+		}
+
+		_, err = From[articleRow](t.db, "article").Get(ctx, a1.ID)
+		if err != sql.ErrNoRows {
+			t.Errorf(`expected sql.ErrNoRows for deleted article, but actual %v`, err)
+		}
+
+		if cnt := count(t.db.Table("article").Model(articleRow{})); cnt != 2 {
+			t.Errorf(`expected count is 2, but actual %d`, cnt)
+		}
+		if cnt := count(t.db.Table("article").Model(articleRow{}).WithDeleted()); cnt != 3 {
+			t.Errorf(`expected count with deleted is 3, but actual %d`, cnt)
+		}
+		if cnt := count(t.db.Table("article")); cnt != 3 {
+			t.Errorf(`expected count without model is 3, but actual %d`, cnt)
+		}
+		if cnt := count(t.db.Table("article").Model(plainArticleRow{})); cnt != 3 {
+			t.Errorf(`expected count of model without marker is 3, but actual %d`, cnt)
+		}
+
+		deleted, err := From[articleRow](t.db, "article").OnlyDeleted().All(ctx)
+		if err != nil {
+			t.Fatalf(`failed to fetch articles: %s`, err)
+		}
+		if len(deleted) != 1 || deleted[0].ID != a1.ID || deleted[0].DeletedAt == nil {
+			t.Errorf(`unexpected deleted articles: %v`, deleted)
+		}
+	}
+
+	// Delete by conditions, and deleted row is not updated
+	{
+		err = t.db.Table("article").Model(articleRow{}).WhereEq("title", "a2").Delete(ctx, nil)
+		if err != nil {
+			t.Fatalf(`failed to delete article: %s`, err)
+		}
+		if cnt := count(t.db.Table("article").Model(articleRow{}).OnlyDeleted()); cnt != 2 {
+			t.Errorf(`expected deleted count is 2, but actual %d`, cnt)
+		}
+
+		err = t.db.Table("article").Update(ctx, &articleRow{ID: a1.ID, Title: "resurrected"})
+		if err != nil {
+			t.Fatalf(`failed to update article: %s`, err)
+		}
+		a, err := From[articleRow](t.db, "article").WithDeleted().Get(ctx, a1.ID)
+		if err != nil {
+			t.Fatalf(`failed to get article: %s`, err)
+		}
+		if a.Title != "a1" {
+			t.Errorf(`deleted article should not be updated: %v`, a)
+		}
+	}
+
+	// registered model applies to the table without Model()
+	{
+		err = RegisterModel("article", articleRow{})
+		if err != nil {
+			t.Fatalf(`failed to register model: %s`, err)
+		}
+		defer RegisterModel("article", nil)
+
+		if cnt := count(t.db.Table("article")); cnt != 1 {
+			t.Errorf(`expected count of registered table is 1, but actual %d`, cnt)
+		}
+
+		a3 := articles[2]
+		err = t.db.Table("article").Delete(ctx, &plainArticleRow{ID: a3.ID})
+		if err == nil {
+			t.Errorf(`expected error for Delete by struct without marker`)
+		}
+		err = t.db.Table("article").Model(plainArticleRow{}).WhereEq("title", "a3").Delete(ctx, nil)
+		if err == nil {
+			t.Errorf(`expected error for Delete by model without marker`)
+		}
+		err = t.db.Table("article").WhereEq("title", "a3").Delete(ctx, nil)
+		if err != nil {
+			t.Fatalf(`failed to delete article: %s`, err)
+		}
+		if cnt := count(t.db.Table("article").OnlyDeleted()); cnt != 3 {
+			t.Errorf(`expected deleted count is 3, but actual %d`, cnt)
+		}
+
+		// HardDelete removes the row
+		err = t.db.Table("article").HardDelete(ctx, a1)
+		if err != nil {
+			t.Fatalf(`failed to hard delete article: %s`, err)
+		}
+		if cnt := count(t.db.Table("article").WithDeleted()); cnt != 2 {
+			t.Errorf(`expected count is 2, but actual %d`, cnt)
+		}
+	}
+}
+
+type docRow struct {
+	ID      int
+	Body    string
+	Version int `aqua:",version"`
+}
+
+func (t *TestSuite) testOptimisticLock() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE doc (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+body VARCHAR(80),
+version INTEGER NOT NULL DEFAULT 0
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	d := docRow{Body: "draft"}
+	err = t.db.Table("doc").Create(ctx, &d)
+	if err != nil {
+		t.Fatalf(`failed to create doc: %s`, err)
+	}
+
+	mine, err := From[docRow](t.db, "doc").Get(ctx, d.ID)
+	if err != nil {
+		t.Fatalf(`failed to get doc: %s`, err)
+	}
+	theirs := mine
+
+	// update increments version
+	theirs.Body = "theirs"
+	err = t.db.Table("doc").Update(ctx, &theirs)
+	if err != nil {
+		t.Fatalf(`failed to update doc: %s`, err)
+	}
+	if theirs.Version != 1 {
+		t.Errorf(`expected version of struct is 1, but actual %d`, theirs.Version)
+	}
+
+	// update with old version is refused
+	mine.Body = "mine"
+	err = t.db.Table("doc").Update(ctx, &mine)
+	if !errors.Is(err, ErrStaleObject) {
+		t.Errorf(`expected ErrStaleObject, but actual %v`, err)
+	}
+	if mine.Version != 0 {
+		t.Errorf(`version of stale struct should be kept, but actual %d`, mine.Version)
+	}
+
+	actual, err := From[docRow](t.db, "doc").Get(ctx, d.ID)
+	if err != nil {
+		t.Fatalf(`failed to get doc: %s`, err)
+	}
+	if actual.Body != "theirs" || actual.Version != 1 {
+		t.Errorf(`unexpected doc: %v`, actual)
+	}
+
+	// deleted row is stale too
+	_, err = t.db.Exec(ctx, `DELETE FROM doc`)
+	if err != nil {
+		t.Fatalf(`failed to delete doc: %s`, err)
+	}
+	err = t.db.Table("doc").Update(ctx, &theirs)
+	if !errors.Is(err, ErrStaleObject) {
+		t.Errorf(`expected ErrStaleObject for deleted row, but actual %v`, err)
+	}
+}
+
+type noteRow struct {
+	Snapshot
+	ID    int
+	Title string
+	Body  string
+	Stars int
+	Memo  *string
+}
+
+func (t *TestSuite) testDirtyTracking() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE note (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+title VARCHAR(80),
+body VARCHAR(80),
+stars INTEGER,
+memo VARCHAR(80)
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	memo := "old"
+	n := noteRow{Title: "title", Body: "body", Stars: 3, Memo: &memo}
+	err = t.db.Table("note").Create(ctx, &n)
+	if err != nil {
+		t.Fatalf(`failed to create note: %s`, err)
+	}
+
+	fetch := func() noteRow {
+		actual, err := From[noteRow](t.db, "note").Get(ctx, n.ID)
+		if err != nil {
+			t.Fatalf(`failed to get note: %s`, err)
+		}
+		return actual
+	}
+
+	// only changed fields are written, including zero value
+	loaded := fetch()
+	_, err = t.db.Exec(ctx, `UPDATE note SET body = 'others'`)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	loaded.Title = "changed"
+	loaded.Stars = 0
+	err = t.db.Table("note").Update(ctx, &loaded)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	actual := fetch()
+	if actual.Title != "changed" || actual.Body != "others" || actual.Stars != 0 {
+		t.Errorf(`unexpected note: %v`, actual)
+	}
+
+	// snapshot is retaken after Update, and Create
+	loaded.Body = "mine"
+	err = t.db.Table("note").Update(ctx, &loaded)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	_, err = t.db.Exec(ctx, `UPDATE note SET title = 'others'`)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	n.Stars = 5
+	err = t.db.Table("note").Update(ctx, &n)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	actual = fetch()
+	if actual.Title != "others" || actual.Body != "mine" || actual.Stars != 5 {
+		t.Errorf(`unexpected note: %v`, actual)
+	}
+
+	// in-place edit through pointer is detected
+	*loaded.Memo = "new"
+	err = t.db.Table("note").Update(ctx, &loaded)
+	if err != nil {
+		t.Fatalf(`failed to update note: %s`, err)
+	}
+	if actual := fetch(); actual.Memo == nil || *actual.Memo != "new" {
+		t.Errorf(`expected memo is updated in place, but actual %v`, actual.Memo)
+	}
+
+	// before image given by Since
+	{
+		before, err := From[docRow](t.db, "doc").First(ctx)
+		if err == sql.ErrNoRows {
+			before = docRow{Body: "doc"}
+			err = t.db.Table("doc").Create(ctx, &before)
+		}
+		if err != nil {
+			t.Fatalf(`failed to prepare doc: %s`, err)
+		}
+		after := before
+		after.Body = ""
+		err = t.db.Table("doc").Since(before).Update(ctx, &after)
+		if err != nil {
+			t.Fatalf(`failed to update doc: %s`, err)
+		}
+		actual, err := From[docRow](t.db, "doc").Get(ctx, before.ID)
+		if err != nil {
+			t.Fatalf(`failed to get doc: %s`, err)
+		}
+		if actual.Body != "" || actual.Version != before.Version+1 {
+			t.Errorf(`unexpected doc: %v`, actual)
+		}
+
+		err = t.db.Table("doc").Since(noteRow{}).Update(ctx, &after)
+		if err == nil {
+			t.Errorf(`expected error for before image of other type`)
+		}
+	}
+
+	// nothing changed, no query even for the dropped table
+	_, err = t.db.Exec(ctx, `DROP TABLE note`)
+	if err != nil {
+		t.Fatalf(`failed to drop table: %s`, err)
+	}
+	err = t.db.Table("note").Update(ctx, &loaded)
+	if err != nil {
+		t.Errorf(`expected no-op for unchanged note, but %s`, err)
+	}
+}
+
+type widgetRow struct {
+	ID    int
+	Name  string
+	Color string
+	Qty   int
+}
+
+func (t *TestSuite) testOnlyOmit() {
+	ctx := context.Background()
+
+	_, err := t.db.Exec(ctx, `CREATE TABLE widget (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+name VARCHAR(80),
+color VARCHAR(80) NOT NULL DEFAULT 'red',
+qty INTEGER
+)`)
+	if err != nil {
+		t.Fatalf(`failed to create table: %s`, err)
+	}
+
+	fetch := func(id int) widgetRow {
+		actual, err := From[widgetRow](t.db, "widget").Get(ctx, id)
+		if err != nil {
+			t.Fatalf(`failed to get widget: %s`, err)
+		}
+		return actual
+	}
+
+	// omitted column is defaulted by the database
+	w := widgetRow{Name: "bolt", Qty: 10}
+	err = t.db.Table("widget").Omit("color").Create(ctx, &w)
+	if err != nil {
+		t.Fatalf(`failed to create widget: %s`, err)
+	}
+	if w.ID == 0 {
+		t.Errorf(`expected autoincrement id is filled`)
+	}
+	if actual := fetch(w.ID); actual.Color != "red" || actual.Qty != 10 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+
+	// only named columns, zero value included
+	err = t.db.Table("widget").Only("qty", "color").Update(ctx, &widgetRow{ID: w.ID, Name: "nut", Color: "blue"})
+	if err != nil {
+		t.Fatalf(`failed to update widget: %s`, err)
+	}
+	if actual := fetch(w.ID); actual.Name != "bolt" || actual.Color != "blue" || actual.Qty != 0 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+
+	// omitted column is kept
+	err = t.db.Table("widget").Omit("name").Update(ctx, &widgetRow{ID: w.ID, Name: "nut", Qty: 7})
+	if err != nil {
+		t.Fatalf(`failed to update widget: %s`, err)
+	}
+	if actual := fetch(w.ID); actual.Name != "bolt" || actual.Color != "blue" || actual.Qty != 7 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+
+	// zero value named on Create
+	z := widgetRow{Name: "zero"}
+	err = t.db.Table("widget").Only("name", "qty").Create(ctx, &z)
+	if err != nil {
+		t.Fatalf(`failed to create widget: %s`, err)
+	}
+	if actual := fetch(z.ID); actual.Name != "zero" || actual.Color != "red" || actual.Qty != 0 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+
+	// keys of map are restricted too
+	m := map[string]interface{}{"name": "washer", "color": "green", "qty": 3}
+	err = t.db.Table("widget").Omit("color").Create(ctx, m)
+	if err != nil {
+		t.Fatalf(`failed to create widget: %s`, err)
+	}
+	row, err := t.db.Table("widget").Select("id").Where("name = ?", "washer").Single(ctx)
+	if err != nil {
+		t.Fatalf(`failed to get widget: %s`, err)
+	}
+	var id int
+	err = row.Scan(&id)
+	if err != nil {
+		t.Fatalf(`failed to scan widget: %s`, err)
+	}
+	if actual := fetch(id); actual.Color != "red" || actual.Qty != 3 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+	err = t.db.Table("widget").Where("id = ?", id).Only("qty").Update(ctx, map[string]interface{}{"name": "nut", "qty": 4})
+	if err != nil {
+		t.Fatalf(`failed to update widget: %s`, err)
+	}
+	if actual := fetch(id); actual.Name != "washer" || actual.Qty != 4 {
+		t.Errorf(`unexpected widget: %v`, actual)
+	}
+
+	err = t.db.Table("widget").Only("no_such_column").Update(ctx, &w)
+	if err == nil {
+		t.Errorf(`expected error for unknown column`)
+	}
+}
+
 func (t *TestSuite) testMisc() {
 	// just call, no check
 	t.db.GetProvider()
@@ -0,0 +1,193 @@
+package aqua
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TimePolicy normalizes time.Time in binds and scans, so that timestamps
+// round-trip the same way whatever the driver returns. Zero value leaves
+// times as they are.
+type TimePolicy struct {
+	UTC       bool           // bind times in UTC
+	Location  *time.Location // location of scanned times, nil leaves as is
+	Precision time.Duration  // truncate times in binds and scans, 0 for none
+}
+
+func (p TimePolicy) bind(t time.Time) time.Time {
+	if p.Precision > 0 {
+		t = t.Truncate(p.Precision)
+	}
+	if p.UTC {
+		t = t.UTC()
+	}
+	return t
+}
+
+func (p TimePolicy) scan(t time.Time) time.Time {
+	if p.Precision > 0 {
+		t = t.Truncate(p.Precision)
+	}
+	if p.Location != nil {
+		t = t.In(p.Location)
+	}
+	return t
+}
+
+// Bind returns v normalized when it is time.Time, *time.Time, sql.NullTime
+// or Null[time.Time]. Other values are returned as is.
+func (p TimePolicy) Bind(v interface{}) interface{} {
+	if p == (TimePolicy{}) {
+		return v
+	}
+
+	switch t := v.(type) {
+	case time.Time:
+		return p.bind(t)
+	case *time.Time:
+		if t != nil {
+			return p.bind(*t)
+		}
+	case sql.NullTime:
+		if t.Valid {
+			t.Time = p.bind(t.Time)
+		}
+		return t
+	case Null[time.Time]:
+		if t.Valid {
+			t.V = p.bind(t.V)
+		}
+		return t
+	}
+	return v
+}
+
+// Normalize normalizes scanned time pointed by dest, which is *time.Time,
+// **time.Time, *sql.NullTime or *Null[time.Time]. Other types are ignored.
+func (p TimePolicy) Normalize(dest interface{}) {
+	if p == (TimePolicy{}) {
+		return
+	}
+
+	switch t := dest.(type) {
+	case *time.Time:
+		*t = p.scan(*t)
+	case **time.Time:
+		if *t != nil {
+			v := p.scan(**t)
+			*t = &v
+		}
+	case *sql.NullTime:
+		if t.Valid {
+			t.Time = p.scan(t.Time)
+		}
+	case *Null[time.Time]:
+		if t.Valid {
+			t.V = p.scan(t.V)
+		}
+	case *interface{}:
+		if v, ok := (*t).(time.Time); ok {
+			*t = p.scan(v)
+		}
+	}
+}
+
+// normalizeValue normalizes settable v in place
+func (p TimePolicy) normalizeValue(v reflect.Value) {
+	if v.CanAddr() {
+		p.Normalize(v.Addr().Interface())
+	}
+}
+
+// timeFormats are layouts of times stored as text, like sqlite TEXT column
+// or mysql without parseTime. Times without zone are in UTC.
+var timeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses time stored as text in one of timeFormats.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "Z")
+	for _, layout := range timeFormats {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(`cannot parse "%s" as time`, s)
+}
+
+// timeScanner scans time.Time, or text of time, into a field of time.Time,
+// *time.Time, sql.NullTime or Null[time.Time]
+type timeScanner struct {
+	typ   reflect.Type
+	dest  reflect.Value // pointer to set on Scan, or invalid for a field
+	t     time.Time
+	valid bool
+}
+
+var timeTypes = map[reflect.Type]bool{
+	reflect.TypeOf(time.Time{}):       true,
+	reflect.TypeOf((*time.Time)(nil)): true,
+	reflect.TypeOf(sql.NullTime{}):    true,
+	reflect.TypeOf(Null[time.Time]{}): true,
+}
+
+// newTimeScanner returns timeScanner for field type t, or nil
+func newTimeScanner(t reflect.Type) *timeScanner {
+	if !timeTypes[t] {
+		return nil
+	}
+	return &timeScanner{typ: t}
+}
+
+func (s *timeScanner) Scan(src interface{}) error {
+	s.valid = src != nil
+	switch v := src.(type) {
+	case nil:
+	case time.Time:
+		s.t = v
+	case string:
+		t, err := ParseTime(v)
+		if err != nil {
+			return err
+		}
+		s.t = t
+	case []byte:
+		t, err := ParseTime(string(v))
+		if err != nil {
+			return err
+		}
+		s.t = t
+	default:
+		return fmt.Errorf(`cannot scan %T into %s`, src, s.typ)
+	}
+
+	if s.dest.IsValid() {
+		if v := s.value(); v.IsValid() {
+			s.dest.Elem().Set(v)
+		} else {
+			s.dest.Elem().Set(reflect.Zero(s.typ))
+		}
+	}
+	return nil
+}
+
+// value returns scanned time as the field type, or invalid Value for NULL
+func (s *timeScanner) value() reflect.Value {
+	if !s.valid {
+		return reflect.Value{}
+	}
+	v := reflect.New(s.typ).Elem()
+	setTime(v, s.t)
+	return v
+}
@@ -0,0 +1,60 @@
+package aqua
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestTimePolicy(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	p := TimePolicy{UTC: true, Location: jst, Precision: time.Second}
+	v := time.Date(2017, 6, 10, 16, 40, 50, 999, jst)
+
+	if b := p.Bind(v).(time.Time); b != time.Date(2017, 6, 10, 7, 40, 50, 0, time.UTC) {
+		t.Errorf(`unexpected bound time: %v`, b)
+	}
+	if b := p.Bind(NewNull(v)).(Null[time.Time]); b.V.Location() != time.UTC {
+		t.Errorf(`unexpected bound Null: %v`, b)
+	}
+	if b := p.Bind((*time.Time)(nil)); b != (*time.Time)(nil) {
+		t.Errorf(`expected nil pointer as is, but actual %v`, b)
+	}
+
+	u := v.UTC()
+	p.Normalize(&u)
+	if u.Location() != jst || u.Nanosecond() != 0 {
+		t.Errorf(`unexpected scanned time: %v`, u)
+	}
+
+	nt := sql.NullTime{Time: v.UTC(), Valid: true}
+	p.Normalize(&nt)
+	if nt.Time.Location() != jst {
+		t.Errorf(`unexpected scanned NullTime: %v`, nt)
+	}
+
+	if (TimePolicy{}).Bind(v) != v {
+		t.Errorf(`zero policy should leave time as is`)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2017, 6, 10, 23, 40, 50, 123000000, time.UTC)
+	cases := []string{
+		"2017-06-10 23:40:50.123",
+		"2017-06-10T23:40:50.123Z",
+		"2017-06-10 23:40:50.123+00:00",
+		"2017-06-11 08:40:50.123+09:00",
+		"2017-06-10 23:40:50.123 +0000 UTC",
+	}
+	for _, c := range cases {
+		actual, err := ParseTime(c)
+		if err != nil || !actual.Equal(expected) {
+			t.Errorf(`%s: expected %v, but actual %v (%v)`, c, expected, actual, err)
+		}
+	}
+
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Errorf(`expected error for invalid time`)
+	}
+}
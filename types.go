@@ -0,0 +1,212 @@
+package aqua
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EncodeFunc converts a value of registered Go type into a driver value.
+type EncodeFunc func(v interface{}) (driver.Value, error)
+
+// DecodeFunc converts a value from the driver into registered Go type.
+// src is never nil.
+type DecodeFunc func(src interface{}) (interface{}, error)
+
+type converter struct {
+	typ    reflect.Type
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+var (
+	typeMutex sync.RWMutex
+	// dialect name => Go type, "" for every dialect
+	types = map[string]map[reflect.Type]*converter{}
+)
+
+// RegisterType registers conversion of goType applied by providers in bind
+// and scan, for the dialects or for every dialect when none is given.
+// Registration for the dialect takes precedence over that for every dialect.
+//
+//	RegisterType(reflect.TypeOf(uuid.UUID{}), encodeText, decodeText, "sqlite3", "mysql")
+func RegisterType(goType reflect.Type, encode EncodeFunc, decode DecodeFunc, dialects ...string) {
+	if len(dialects) == 0 {
+		dialects = []string{""}
+	}
+
+	typeMutex.Lock()
+	defer typeMutex.Unlock()
+
+	c := &converter{typ: goType, encode: encode, decode: decode}
+	for _, d := range dialects {
+		if types[d] == nil {
+			types[d] = map[reflect.Type]*converter{}
+		}
+		types[d][goType] = c
+	}
+}
+
+func lookupType(dialect string, t reflect.Type) *converter {
+	typeMutex.RLock()
+	defer typeMutex.RUnlock()
+
+	if c, ok := types[dialect][t]; ok {
+		return c
+	}
+	return types[""][t]
+}
+
+// RegisteredType reports whether t is registered for any dialect.
+func RegisteredType(t reflect.Type) bool {
+	typeMutex.RLock()
+	defer typeMutex.RUnlock()
+
+	for _, m := range types {
+		if _, ok := m[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// typedValue defers encoding of registered type to database/sql, which
+// reports the error of encode
+type typedValue struct {
+	v      interface{}
+	encode EncodeFunc
+}
+
+func (v typedValue) Value() (driver.Value, error) {
+	return v.encode(v.v)
+}
+
+func (v typedValue) String() string {
+	return fmt.Sprint(v.v)
+}
+
+// Encode returns v wrapped in driver.Valuer when its type, or the type it
+// points to, is registered for the dialect. Otherwise v is returned as is.
+func Encode(dialect string, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if c := lookupType(dialect, rv.Type()); c != nil {
+		return typedValue{v: v, encode: c.encode}
+	}
+	if rv.Kind() == reflect.Ptr {
+		if c := lookupType(dialect, rv.Type().Elem()); c != nil {
+			if rv.IsNil() {
+				return nil
+			}
+			return typedValue{v: rv.Elem().Interface(), encode: c.encode}
+		}
+	}
+
+	return v
+}
+
+// typeScanner decodes a column into registered type
+type typeScanner struct {
+	conv  *converter
+	dest  reflect.Value // pointer to the registered type
+	ptr   bool          // field is a pointer to the registered type
+	valid bool
+}
+
+// decoder returns sql.Scanner for field type t when t, or the type it
+// points to, is registered for the dialect
+func decoder(dialect string, t reflect.Type) *typeScanner {
+	if c := lookupType(dialect, t); c != nil {
+		return &typeScanner{conv: c, dest: reflect.New(t)}
+	}
+	if t.Kind() == reflect.Ptr {
+		if c := lookupType(dialect, t.Elem()); c != nil {
+			return &typeScanner{conv: c, dest: reflect.New(t.Elem()), ptr: true}
+		}
+	}
+	return nil
+}
+
+func (s *typeScanner) Scan(src interface{}) error {
+	s.valid = src != nil
+	if !s.valid {
+		s.dest.Elem().Set(reflect.Zero(s.conv.typ))
+		return nil
+	}
+	if b, ok := src.([]byte); ok {
+		// driver may reuse the buffer
+		src = append([]byte{}, b...)
+	}
+
+	v, err := s.conv.decode(src)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !rv.Type().AssignableTo(s.conv.typ) {
+		return fmt.Errorf(`decoder of %s returned %T`, s.conv.typ, v)
+	}
+	s.dest.Elem().Set(rv)
+
+	return nil
+}
+
+// value returns decoded value for the field, or invalid Value for NULL
+func (s *typeScanner) value() reflect.Value {
+	if !s.valid {
+		return reflect.Value{}
+	}
+	if s.ptr {
+		return s.dest
+	}
+	return s.dest.Elem()
+}
+
+// Decoder returns sql.Scanner decoding into dest, a pointer, when the type
+// of *dest is registered for the dialect, or is a time which may be stored
+// as text. Otherwise dest is returned as is.
+func Decoder(dialect string, dest interface{}) interface{} {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return dest
+	}
+
+	s := decoder(dialect, rv.Type().Elem())
+	if s == nil {
+		if ts := newTimeScanner(rv.Type().Elem()); ts != nil {
+			// time stored as text
+			ts.dest = rv
+			return ts
+		}
+		return dest
+	}
+	if !s.ptr {
+		s.dest = rv
+		return s
+	}
+	return &ptrScanner{typeScanner: s, dest: rv}
+}
+
+// ptrScanner decodes into pointer to registered type
+type ptrScanner struct {
+	*typeScanner
+	dest reflect.Value // pointer to pointer
+}
+
+func (s *ptrScanner) Scan(src interface{}) error {
+	err := s.typeScanner.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	if s.valid {
+		s.dest.Elem().Set(s.typeScanner.dest)
+	} else {
+		s.dest.Elem().Set(reflect.Zero(s.dest.Elem().Type()))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package aqua
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testEnum int
+
+func TestRegisterType(t *testing.T) {
+	typ := reflect.TypeOf(testEnum(0))
+	RegisterType(typ,
+		func(v interface{}) (driver.Value, error) {
+			return int64(v.(testEnum)), nil
+		},
+		func(src interface{}) (interface{}, error) {
+			return testEnum(src.(int64)), nil
+		})
+	RegisterType(typ,
+		func(v interface{}) (driver.Value, error) {
+			return strings.Repeat("*", int(v.(testEnum))), nil
+		},
+		func(src interface{}) (interface{}, error) {
+			return testEnum(len(src.(string))), nil
+		}, "postgres")
+
+	cases := []struct {
+		dialect string
+		encoded driver.Value
+	}{
+		{"sqlite3", int64(3)},
+		{"postgres", "***"},
+	}
+	for _, c := range cases {
+		e := testEnum(3)
+		for _, v := range []interface{}{e, &e} {
+			valuer, ok := Encode(c.dialect, v).(driver.Valuer)
+			if !ok {
+				t.Fatalf(`%s: expected Valuer for %T`, c.dialect, v)
+			}
+			actual, err := valuer.Value()
+			if err != nil || actual != c.encoded {
+				t.Errorf(`%s: expected %#v, but actual %#v (%v)`, c.dialect, c.encoded, actual, err)
+			}
+		}
+
+		var dest *testEnum
+		err := Decoder(c.dialect, &dest).(interface{ Scan(interface{}) error }).Scan(c.encoded)
+		if err != nil || dest == nil || *dest != 3 {
+			t.Errorf(`%s: failed to decode %#v: %v`, c.dialect, c.encoded, err)
+		}
+	}
+
+	if Encode("sqlite3", (*testEnum)(nil)) != nil {
+		t.Errorf(`expected nil for nil pointer`)
+	}
+	if Encode("sqlite3", 1) != 1 {
+		t.Errorf(`expected unregistered value as is`)
+	}
+	if !RegisteredType(typ) || RegisteredType(reflect.TypeOf(0)) {
+		t.Errorf(`unexpected RegisteredType`)
+	}
+}